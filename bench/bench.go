@@ -0,0 +1,180 @@
+// Package bench cung cấp công cụ tạo tải in-process và các benchmark tham chiếu
+// đo chi phí (ns/op, allocs/op) của từng middleware trong package middleware,
+// để phát hiện regression hiệu năng và giúp người dùng ước lượng chi phí khi
+// bật một tính năng.
+//
+// # Chạy benchmark:
+//
+//	go test ./bench -run '^$' -bench . -benchmem
+//
+// # Chạy soak test (tải liên tục, mặc định tắt):
+//
+//	BENCH_SOAK=30s go test ./bench -run Soak -v
+package bench
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kimxuanhong/go-middleware/middleware"
+)
+
+// NopLogger là Logger bỏ qua mọi entry, để benchmark chỉ đo chi phí của middleware
+type NopLogger struct{}
+
+// LogRequest implements middleware.Logger
+func (NopLogger) LogRequest(middleware.LogEntry) {}
+
+// LogResponse implements middleware.Logger
+func (NopLogger) LogResponse(middleware.LogEntry) {}
+
+// LogError implements middleware.Logger
+func (NopLogger) LogError(string, error) {}
+
+// LogMessage implements middleware.MessageLogger
+func (NopLogger) LogMessage(string) {}
+
+// NewEngine tạo engine ở release mode với các middleware cho trước và hai route
+// tham chiếu: GET /bench trả JSON nhỏ, POST /bench trả lại body nhận được
+func NewEngine(handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(handlers...)
+	engine.GET("/bench", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+	})
+	engine.POST("/bench", func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.Data(http.StatusOK, "application/json", body)
+	})
+	return engine
+}
+
+// NewRequest tạo request tới handler in-process; body nil là không có body
+func NewRequest(method, path string, body []byte) *http.Request {
+	var req *http.Request
+	if body == nil {
+		req, _ = http.NewRequest(method, path, nil)
+	} else {
+		req, _ = http.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "go-middleware-bench")
+	return req
+}
+
+// discardWriter là http.ResponseWriter bỏ qua body, rẻ hơn httptest.ResponseRecorder
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardWriter) Header() http.Header { return w.header }
+
+func (w *discardWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (w *discardWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Serve gửi một request qua handler và trả về status của response
+func Serve(handler http.Handler, req *http.Request) int {
+	w := &discardWriter{header: make(http.Header)}
+	handler.ServeHTTP(w, req)
+	return w.status
+}
+
+// LoadConfig cấu hình cho RunLoad
+type LoadConfig struct {
+	Handler     http.Handler         // Handler nhận tải, bắt buộc
+	Request     func() *http.Request // Tạo request cho mỗi lần gửi, bắt buộc
+	Concurrency int                  // Số worker đồng thời, mặc định 8
+	// Duration là thời gian chạy tải; Requests giới hạn tổng số request.
+	// Dừng khi đạt điều kiện nào tới trước, mặc định chạy 10 giây.
+	Duration time.Duration
+	Requests int
+}
+
+// LoadResult là kết quả của một lần RunLoad
+type LoadResult struct {
+	Requests    int
+	Duration    time.Duration
+	Throughput  float64 // Request mỗi giây
+	P50         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+	StatusCodes map[int]int
+}
+
+// RunLoad tạo tải liên tục lên handler từ Concurrency worker và tổng hợp latency
+// cùng phân bố status code
+func RunLoad(config LoadConfig) LoadResult {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 8
+	}
+	if config.Duration <= 0 && config.Requests <= 0 {
+		config.Duration = 10 * time.Second
+	}
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		statuses  = make(map[int]int)
+		issued    atomic.Int64
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	deadline := start.Add(config.Duration)
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local []time.Duration
+			localStatuses := make(map[int]int)
+			for {
+				if config.Requests > 0 && issued.Add(1) > int64(config.Requests) {
+					break
+				}
+				if config.Duration > 0 && time.Now().After(deadline) {
+					break
+				}
+				begin := time.Now()
+				status := Serve(config.Handler, config.Request())
+				local = append(local, time.Since(begin))
+				localStatuses[status]++
+			}
+			mu.Lock()
+			latencies = append(latencies, local...)
+			for status, n := range localStatuses {
+				statuses[status] += n
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	result := LoadResult{Requests: len(latencies), Duration: elapsed, StatusCodes: statuses}
+	if len(latencies) == 0 {
+		return result
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	result.P50 = latencies[len(latencies)/2]
+	result.P99 = latencies[(len(latencies)*99+99)/100-1]
+	result.Max = latencies[len(latencies)-1]
+	return result
+}
@@ -0,0 +1,115 @@
+package bench
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kimxuanhong/go-middleware/middleware"
+)
+
+func TestMain(m *testing.M) {
+	middleware.SetLogger(NopLogger{})
+	os.Exit(m.Run())
+}
+
+var payload = []byte(`{"id":42,"name":"bench","tags":["a","b","c"],"nested":{"value":3.14}}`)
+
+// benchmarkHandlers đo chi phí của một cấu hình middleware cho GET và POST có body
+func benchmarkHandlers(b *testing.B, handlers ...gin.HandlerFunc) {
+	engine := NewEngine(handlers...)
+	b.Run("GET", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Serve(engine, NewRequest(http.MethodGet, "/bench", nil))
+		}
+	})
+	b.Run("POST", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Serve(engine, NewRequest(http.MethodPost, "/bench", payload))
+		}
+	})
+}
+
+func BenchmarkBaseline(b *testing.B) {
+	benchmarkHandlers(b)
+}
+
+func BenchmarkRequestID(b *testing.B) {
+	benchmarkHandlers(b, middleware.RequestIDMiddleware())
+}
+
+func BenchmarkRecovery(b *testing.B) {
+	benchmarkHandlers(b, middleware.RecoveryMiddleware())
+}
+
+func BenchmarkLogging(b *testing.B) {
+	benchmarkHandlers(b, middleware.LogRequestMiddleware(), middleware.LogResponseMiddleware())
+}
+
+func BenchmarkLoggingSampled(b *testing.B) {
+	benchmarkHandlers(b,
+		middleware.LogSamplingMiddleware(middleware.LogSamplingConfig{SampleRate: 0.1}),
+		middleware.LogRequestMiddleware(), middleware.LogResponseMiddleware())
+}
+
+func BenchmarkLoggingAsync(b *testing.B) {
+	async := middleware.NewAsyncLogger(NopLogger{}, middleware.AsyncLoggerConfig{})
+	middleware.SetLogger(async)
+	defer func() {
+		middleware.SetLogger(NopLogger{})
+		_ = async.Close(context.Background())
+	}()
+	benchmarkHandlers(b, middleware.LogRequestMiddleware(), middleware.LogResponseMiddleware())
+}
+
+func BenchmarkRateLimit(b *testing.B) {
+	limiter := middleware.NewTokenBucketLimiter(1e9, 1e9)
+	benchmarkHandlers(b, middleware.RateLimitMiddleware(middleware.RateLimitConfig{Limiter: limiter}))
+}
+
+func BenchmarkLoadShedding(b *testing.B) {
+	benchmarkHandlers(b, middleware.LoadSheddingMiddleware(middleware.LoadSheddingConfig{MaxInFlight: 1 << 20}))
+}
+
+func BenchmarkFullStack(b *testing.B) {
+	limiter := middleware.NewTokenBucketLimiter(1e9, 1e9)
+	benchmarkHandlers(b,
+		middleware.RequestIDMiddleware(),
+		middleware.RecoveryMiddleware(),
+		middleware.LoadSheddingMiddleware(middleware.LoadSheddingConfig{MaxInFlight: 1 << 20}),
+		middleware.RateLimitMiddleware(middleware.RateLimitConfig{Limiter: limiter}),
+		middleware.LogRequestMiddleware(),
+		middleware.LogResponseMiddleware())
+}
+
+// TestSoak chạy tải liên tục lên full stack trong thời gian đặt bởi BENCH_SOAK
+// (ví dụ "30s") và báo lỗi nếu có response 5xx
+func TestSoak(t *testing.T) {
+	duration, err := time.ParseDuration(os.Getenv("BENCH_SOAK"))
+	if err != nil {
+		t.Skip("set BENCH_SOAK to a duration to run the soak test")
+	}
+	engine := NewEngine(
+		middleware.RequestIDMiddleware(),
+		middleware.RecoveryMiddleware(),
+		middleware.LogRequestMiddleware(),
+		middleware.LogResponseMiddleware())
+
+	result := RunLoad(LoadConfig{
+		Handler:  engine,
+		Request:  func() *http.Request { return NewRequest(http.MethodPost, "/bench", payload) },
+		Duration: duration,
+	})
+	t.Logf("%d requests in %v (%.0f req/s), p50 %v, p99 %v, max %v, status %v",
+		result.Requests, result.Duration, result.Throughput, result.P50, result.P99, result.Max, result.StatusCodes)
+	for status, n := range result.StatusCodes {
+		if status >= 500 {
+			t.Errorf("%d responses with status %d", n, status)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package bodyproc cung cấp các hàm xử lý body request/response dùng bởi package
+// middleware: đọc (capture) body, compact JSON, biến đổi/ẩn field và kiểm tra JSON.
+// Các hàm này nhận input không tin cậy từ client nên được tách riêng để có fuzz
+// test và có thể dùng lại bên ngoài middleware. Mọi hàm đều không panic với input
+// bất kỳ; body không phải JSON hợp lệ được giữ nguyên.
+package bodyproc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RedactedValue là giá trị thay thế cho field bị ẩn bởi Redact
+const RedactedValue = "[REDACTED]"
+
+// ErrTooDeep được trả về bởi Validate khi JSON lồng nhau sâu hơn MaxDepth
+var ErrTooDeep = errors.New("bodyproc: json nesting too deep")
+
+// ErrTooLarge được trả về bởi Validate khi body dài hơn MaxBytes
+var ErrTooLarge = errors.New("bodyproc: body too large")
+
+// Capture đọc tối đa limit byte của body (limit <= 0 là không giới hạn) và trả về
+// phần đã đọc cùng một ReadCloser thay thế phát lại toàn bộ body gốc, để handler
+// phía sau vẫn đọc được. truncated cho biết body dài hơn limit. Khi đọc lỗi,
+// phần đã đọc được vẫn được trả về và phát lại.
+func Capture(body io.ReadCloser, limit int64) (captured []byte, restored io.ReadCloser, truncated bool, err error) {
+	if body == nil {
+		return nil, nil, false, nil
+	}
+	if limit <= 0 {
+		captured, err = io.ReadAll(body)
+		return captured, io.NopCloser(bytes.NewReader(captured)), false, err
+	}
+
+	// Đọc thêm 1 byte để biết body có dài hơn limit hay không
+	captured, err = io.ReadAll(io.LimitReader(body, limit+1))
+	if int64(len(captured)) > limit {
+		truncated = true
+	}
+	restored = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(captured), body),
+		Closer: body,
+	}
+	if truncated {
+		captured = captured[:limit]
+	}
+	return captured, restored, truncated, err
+}
+
+// readCloser ghép Reader phát lại với Closer của body gốc
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// IsMultipart cho biết Content-Type có phải multipart/form-data hay không
+// (body loại này thường lớn và không được capture để log)
+func IsMultipart(contentType string) bool {
+	return strings.HasPrefix(contentType, "multipart/form-data")
+}
+
+// Compact loại bỏ khoảng trắng thừa của body JSON; body không phải JSON được giữ nguyên
+func Compact(data string) string {
+	if data == "" {
+		return ""
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(data)); err != nil {
+		return data
+	}
+	return compacted.String()
+}
+
+// TransformFields thay giá trị của các field JSON có tên trong fields (không phân
+// biệt hoa thường), ở mọi cấp lồng nhau, bằng kết quả của fn. fn nhận giá trị JSON
+// gốc của field đã encode. Body không phải JSON hoặc không có fields được giữ nguyên.
+func TransformFields(body string, fields []string, fn func(raw []byte) interface{}) string {
+	if body == "" || len(fields) == 0 {
+		return body
+	}
+	targets := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		targets[strings.ToLower(f)] = true
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return body
+	}
+	transformed, err := json.Marshal(transformFields(targets, data, fn))
+	if err != nil {
+		return body
+	}
+	return string(transformed)
+}
+
+// transformFields duyệt đệ quy dữ liệu JSON và thay các field trùng tên
+func transformFields(targets map[string]bool, data interface{}, fn func(raw []byte) interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if targets[strings.ToLower(k)] {
+				raw, _ := json.Marshal(val)
+				v[k] = fn(raw)
+				continue
+			}
+			v[k] = transformFields(targets, val, fn)
+		}
+		return v
+	case []interface{}:
+		for i, val := range v {
+			v[i] = transformFields(targets, val, fn)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// Redact thay giá trị của các field JSON có tên trong fields bằng RedactedValue
+func Redact(body string, fields ...string) string {
+	return TransformFields(body, fields, func([]byte) interface{} { return RedactedValue })
+}
+
+// Limits giới hạn cho Validate; giá trị <= 0 là không giới hạn
+type Limits struct {
+	MaxBytes int // Độ dài tối đa của body
+	MaxDepth int // Độ sâu lồng nhau tối đa của object/array
+}
+
+// Validate kiểm tra data là một giá trị JSON hợp lệ duy nhất và nằm trong limits
+func Validate(data []byte, limits Limits) error {
+	if limits.MaxBytes > 0 && len(data) > limits.MaxBytes {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrTooLarge, len(data), limits.MaxBytes)
+	}
+	if limits.MaxDepth > 0 {
+		if depth := maxDepth(data); depth > limits.MaxDepth {
+			return fmt.Errorf("%w: depth %d, limit %d", ErrTooDeep, depth, limits.MaxDepth)
+		}
+	}
+	if !json.Valid(data) {
+		return errors.New("bodyproc: invalid json")
+	}
+	return nil
+}
+
+// maxDepth trả về độ sâu lồng nhau lớn nhất của object/array, bỏ qua nội dung chuỗi.
+// Chỉ quét byte nên không tốn bộ nhớ kể cả với input cố tình lồng rất sâu.
+func maxDepth(data []byte) int {
+	depth, deepest := 0, 0
+	inString, escaped := false, false
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > deepest {
+				deepest = depth
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return deepest
+}
@@ -0,0 +1,125 @@
+package bodyproc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var seeds = []string{
+	``,
+	`{}`,
+	`{"a": 1, "password": "secret"}`,
+	`[{"Password": {"nested": [1, 2, 3]}}, null, true]`,
+	`{"a": "\"}{]["}`,
+	`  "string"  `,
+	`{"a":`,
+	`[[[[[[[[[[]]]]]]]]]]`,
+	"not json \x00\xff",
+}
+
+func FuzzCapture(f *testing.F) {
+	for _, s := range seeds {
+		f.Add([]byte(s), int64(4))
+	}
+	f.Fuzz(func(t *testing.T, body []byte, limit int64) {
+		captured, restored, truncated, err := Capture(io.NopCloser(bytes.NewReader(body)), limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(body, captured) {
+			t.Fatalf("captured %q is not a prefix of %q", captured, body)
+		}
+		if truncated != (limit > 0 && int64(len(body)) > limit) {
+			t.Fatalf("truncated = %v for %d bytes with limit %d", truncated, len(body), limit)
+		}
+		replayed, err := io.ReadAll(restored)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(replayed, body) {
+			t.Fatalf("restored body %q, want %q", replayed, body)
+		}
+	})
+}
+
+func FuzzCompact(f *testing.F) {
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		compacted := Compact(data)
+		if !json.Valid([]byte(data)) {
+			if compacted != data {
+				t.Fatalf("invalid json changed: %q -> %q", data, compacted)
+			}
+			return
+		}
+		if !json.Valid([]byte(compacted)) {
+			t.Fatalf("compacted json is invalid: %q", compacted)
+		}
+		if again := Compact(compacted); again != compacted {
+			t.Fatalf("compact is not idempotent: %q -> %q", compacted, again)
+		}
+	})
+}
+
+func FuzzRedact(f *testing.F) {
+	for _, s := range seeds {
+		f.Add(s, "password")
+	}
+	f.Fuzz(func(t *testing.T, data, field string) {
+		redacted := Redact(data, field)
+		if !json.Valid([]byte(data)) || field == "" {
+			return
+		}
+		if !json.Valid([]byte(redacted)) {
+			t.Fatalf("redacted json is invalid: %q", redacted)
+		}
+		var check func(v interface{})
+		check = func(v interface{}) {
+			switch v := v.(type) {
+			case map[string]interface{}:
+				for k, val := range v {
+					if strings.EqualFold(k, field) {
+						if val != RedactedValue {
+							t.Fatalf("field %q not redacted in %q", k, redacted)
+						}
+						continue
+					}
+					check(val)
+				}
+			case []interface{}:
+				for _, val := range v {
+					check(val)
+				}
+			}
+		}
+		var out interface{}
+		if err := json.Unmarshal([]byte(redacted), &out); err != nil {
+			t.Fatal(err)
+		}
+		check(out)
+	})
+}
+
+func FuzzValidate(f *testing.F) {
+	for _, s := range seeds {
+		f.Add([]byte(s), 3)
+	}
+	f.Fuzz(func(t *testing.T, data []byte, depth int) {
+		if got, want := Validate(data, Limits{}) == nil, json.Valid(data); got != want {
+			t.Fatalf("Validate(%q) ok = %v, json.Valid = %v", data, got, want)
+		}
+		err := Validate(data, Limits{MaxDepth: depth})
+		if err == nil && !json.Valid(data) {
+			t.Fatalf("Validate accepted invalid json %q", data)
+		}
+		if errors.Is(err, ErrTooDeep) && depth <= 0 {
+			t.Fatalf("depth limit %d must be ignored", depth)
+		}
+	})
+}
@@ -2,8 +2,16 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
 	"github.com/kimxuanhong/go-logger/logger"
+	"github.com/kimxuanhong/go-middleware/bodyproc"
 )
 
 // Logger interface defines the logging methods
@@ -13,9 +21,37 @@ type Logger interface {
 	LogError(requestID string, err error)
 }
 
+// MessageLogger is an optional interface for loggers that can write free-form
+// messages, used for summaries and reports that are not tied to one request
+type MessageLogger interface {
+	LogMessage(message string)
+}
+
 // DefaultLogger implements Logger interface using standard log package
 type DefaultLogger struct {
 	logger logger.Logger
+	schema LogSchema
+	mu     sync.Mutex
+	output io.Writer
+}
+
+// DefaultLoggerConfig configures NewDefaultLoggerWithConfig
+type DefaultLoggerConfig struct {
+	Logger *logger.Config // Config of the underlying go-logger, default logger.DefaultLogger()
+	// Schema writes records in an access log layout (see LogSchemaEnvoy) as raw
+	// JSON lines to Output instead of through the go-logger, whose line prefix
+	// would keep log collectors from parsing them; LogSchemaDefault keeps the text format
+	Schema LogSchema
+	Output io.Writer // Destination of schema records, default os.Stdout
+}
+
+// Validate checks the DefaultLoggerConfig for invalid values
+func (c DefaultLoggerConfig) Validate() error {
+	var errs configErrors
+	if err := c.Schema.validate(); err != nil {
+		errs.addf("Schema: %v", err)
+	}
+	return errs.err()
 }
 
 // NewDefaultLogger creates a new DefaultLogger
@@ -32,36 +68,142 @@ func NewLogger(config *logger.Config) *DefaultLogger {
 	}
 }
 
+// NewDefaultLoggerWithConfig creates a DefaultLogger with an optional access log schema
+//
+// Panics if config is invalid (see DefaultLoggerConfig.Validate).
+func NewDefaultLoggerWithConfig(config DefaultLoggerConfig) *DefaultLogger {
+	mustValidate("DefaultLogger", config)
+	l := NewDefaultLogger()
+	if config.Logger != nil {
+		l = NewLogger(config.Logger)
+	}
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	l.schema, l.output = config.Schema, config.Output
+	return l
+}
+
+// logSchema writes a record in the configured access log schema, returning
+// false when the logger uses the default text format
+func (l *DefaultLogger) logSchema(record LogRecord) bool {
+	if l.schema == LogSchemaDefault {
+		return false
+	}
+	r := schemaRecord(l.schema, time.Now(), record)
+	if r == nil {
+		return true
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		log.Printf("default logger: encode %s record: %v", record.Kind, err)
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.output.Write(append(line, '\n')); err != nil {
+		log.Printf("default logger: write %s record: %v", record.Kind, err)
+	}
+	return true
+}
+
 // LogRequest implements Logger interface for DefaultLogger
 func (l *DefaultLogger) LogRequest(entry LogEntry) {
+	if l.logSchema(LogRecord{Kind: LogKindRequest, Entry: &entry}) {
+		return
+	}
 	message := fmt.Sprintf("%s %s - %d in %v\nClientIP: %s, UserAgent: %s\nRequest: %s\n",
 		entry.Method, entry.Path,
 		entry.StatusCode,
 		formatDuration(entry.ProcessTime),
 		entry.ClientIP,
 		entry.UserAgent,
-		compactJSON(entry.Request),
+		bodyproc.Compact(entry.Request),
 	)
+	if entry.Fingerprint != "" {
+		message += fmt.Sprintf("Fingerprint: %s\n", entry.Fingerprint)
+	}
+	if len(entry.Labels) > 0 {
+		message += fmt.Sprintf("Labels: %s\n", formatLabels(entry.Labels))
+	}
+	if entry.Headers != "" {
+		message += fmt.Sprintf("Headers: %s\n", entry.Headers)
+	}
+	if entry.DryRun {
+		message = "[DRY-RUN] " + message
+	}
+	if entry.Escalated {
+		message = "[ESCALATED] " + message
+	}
 	ctx := context.WithValue(context.Background(), logger.RequestIDKey, entry.RequestID)
 	l.logger.WithContext(ctx).Info(message)
 }
 
 // LogResponse implements Logger interface for DefaultLogger
 func (l *DefaultLogger) LogResponse(entry LogEntry) {
+	if l.logSchema(LogRecord{Kind: LogKindResponse, Entry: &entry}) {
+		return
+	}
 	message := fmt.Sprintf("%s %s - %d in %v\nClientIP: %s, UserAgent: %s\nResponse: %s\n",
 		entry.Method, entry.Path,
 		entry.StatusCode,
 		formatDuration(entry.ProcessTime),
 		entry.ClientIP,
 		entry.UserAgent,
-		compactJSON(entry.Response),
+		bodyproc.Compact(entry.Response),
 	)
+	if entry.Fingerprint != "" {
+		message += fmt.Sprintf("Fingerprint: %s\n", entry.Fingerprint)
+	}
+	if len(entry.Labels) > 0 {
+		message += fmt.Sprintf("Labels: %s\n", formatLabels(entry.Labels))
+	}
+	if entry.FirstByteTime > 0 {
+		message += fmt.Sprintf("FirstByte: %v\n", formatDuration(entry.FirstByteTime))
+	}
+	if entry.ClientGone {
+		message += "Client closed the connection while the request was being processed\n"
+	}
+	if entry.RateLimit != "" {
+		message += fmt.Sprintf("RateLimit: %s\n", entry.RateLimit)
+	}
+	if entry.AbortedBy != "" {
+		message += fmt.Sprintf("AbortedBy: %s (%s)\n", entry.AbortedBy, entry.AbortReason)
+	}
+	if entry.DryRun {
+		message = "[DRY-RUN] " + message
+	}
+	if entry.Escalated {
+		message = "[ESCALATED] " + message
+	}
 	ctx := context.WithValue(context.Background(), logger.RequestIDKey, entry.RequestID)
 	l.logger.WithContext(ctx).Info("[REQUEST] %v", message)
 }
 
 // LogError implements Logger interface for DefaultLogger
 func (l *DefaultLogger) LogError(requestID string, err error) {
+	if l.logSchema(LogRecord{Kind: LogKindError, RequestID: requestID, Err: err}) {
+		return
+	}
 	ctx := context.WithValue(context.Background(), logger.RequestIDKey, requestID)
 	l.logger.WithContext(ctx).Error("[ERROR] %v", err)
 }
+
+// LogMessage implements MessageLogger interface for DefaultLogger
+func (l *DefaultLogger) LogMessage(message string) {
+	if l.logSchema(LogRecord{Kind: LogKindMessage, Message: message}) {
+		return
+	}
+	l.logger.WithContext(context.Background()).Info("[INFO] %v", message)
+}
+
+// logMessage writes a free-form message through the default logger when it
+// implements MessageLogger, falling back to the standard log package
+func logMessage(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if ml, ok := defaultLogger.(MessageLogger); ok {
+		ml.LogMessage(message)
+		return
+	}
+	log.Println(message)
+}
@@ -11,6 +11,7 @@
 //	func main() {
 //	    r := gin.Default()
 //
+//	    r.Use(middleware.RequestIDMiddleware())
 //	    r.Use(middleware.RecoveryMiddleware())
 //	    r.Use(middleware.LogRequestMiddleware())
 //	    r.Use(middleware.LogResponseMiddleware())
@@ -21,25 +22,42 @@
 //
 //	    r.Run()
 //	}
+//
+// # Quy ước đặt tên
+//
+// Identifier được export đặt tên bằng tiếng Anh (doc comment có thể bằng tiếng
+// Việt) theo các quy ước sau:
+//
+//   - Middleware: <Tên>Middleware(config <Tên>Config). Khi cần dạng không tham
+//     số thì <Tên>() đi kèm <Tên>WithConfig(config), ví dụ RequestIDMiddleware
+//     và RequestIDMiddlewareWithConfig.
+//   - Cấu hình luôn là struct <Tên>Config truyền theo giá trị (không dùng hậu tố
+//     Options); config có ràng buộc được kiểm tra bằng Validate() khi khởi tạo.
+//   - Set<Tên> thay thế một giá trị toàn cục hoặc của request (SetLogger, SetTenant).
+//   - Register<Tên> thêm vào registry toàn cục hoặc đăng ký route lên router
+//     (RegisterMetricsSink, RegisterOpsEndpoints); registry có thể gỡ có
+//     Unregister<Tên> hoặc Reset<Tên> tương ứng.
+//   - Start<Tên> khởi động goroutine nền và trả về hàm stop
+//     (StartLatencyWatchdog, StartNotFoundAggregation).
+//   - Context key là ContextKey<Tên>, accessor cùng tên với giá trị (RequestID, Tenant).
 package middleware
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
-	"strings"
+	"runtime/debug"
 	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	"github.com/kimxuanhong/go-middleware/bodyproc"
 	"github.com/kimxuanhong/go-utils/safe"
 )
 
 var (
 	defaultLogger Logger = NewDefaultLogger()
 	metrics              = NewMetrics()
+	logBodyHook   BodyHook
 )
 
 // SetLogger cho phép thay thế logger mặc định.
@@ -49,6 +67,14 @@ func SetLogger(logger Logger) {
 	defaultLogger = logger
 }
 
+// SetLogBodyHook đăng ký hook biến đổi body request/response trước khi ghi log,
+// ví dụ mã hoá các field nhạy cảm bằng NewFieldEncryptionHook.
+//
+// hook: nil để tắt.
+func SetLogBodyHook(hook BodyHook) {
+	logBodyHook = hook
+}
+
 // GetMetrics trả về con trỏ đến struct Metrics toàn cục
 // chứa các thông tin thống kê hiện tại của hệ thống.
 func GetMetrics() *Metrics {
@@ -57,16 +83,38 @@ func GetMetrics() *Metrics {
 
 // LogEntry đại diện cho một entry log gồm request/response
 type LogEntry struct {
-	StatusCode  int           // HTTP status code
-	Method      string        // HTTP method
-	Path        string        // URL path
-	Request     string        // Request body (JSON, nếu có)
-	Response    string        // Response body (JSON, nếu có)
-	ProcessTime time.Duration // Thời gian xử lý request
-	ClientIP    string        // Địa chỉ IP của client
-	UserAgent   string        // User agent string
-	RequestID   string        // UUID của request
-	Error       string        // Error nếu có panic
+	StatusCode  int               // HTTP status code
+	Method      string            // HTTP method
+	Path        string            // URL path
+	Request     string            // Request body (JSON, nếu có)
+	Response    string            // Response body (JSON, nếu có)
+	ProcessTime time.Duration     // Thời gian xử lý request
+	ClientIP    string            // Địa chỉ IP của client
+	UserAgent   string            // User agent string
+	RequestID   string            // UUID của request
+	Error       string            // Error nếu có panic
+	RateLimit   string            // Quyết định rate limit tổng hợp (nếu có)
+	Labels      map[string]string // Label tĩnh của deployment (xem SetStaticLabels)
+	Fingerprint string            // Fingerprint của client (xem FingerprintMiddleware)
+	DryRun      bool              // Request là dry-run (xem DryRunMiddleware)
+	ClientGone  bool              // Client ngắt kết nối trong lúc request đang được xử lý
+	Headers     string            // Header của request, chỉ có khi log được ép qua debug header hoặc leo thang do lỗi
+	Escalated   bool              // Entry bị sampling/skip bỏ qua nhưng được ghi lại vì request kết thúc bằng 5xx/panic
+	AbortedBy   string            // Middleware đã dừng chain (xem AbortWithReason)
+	AbortReason string            // Lý do dừng chain
+
+	// Các field của access log (xem LogSchemaEnvoy), chỉ có trong entry response
+	StartTime     time.Time // Thời điểm bắt đầu xử lý request
+	URI           string    // Path kèm query (RequestURI)
+	Protocol      string    // Giao thức, ví dụ "HTTP/1.1"
+	Authority     string    // Host của request
+	XForwardedFor string    // Header X-Forwarded-For của request
+	BytesReceived int64     // Kích thước body request (theo Content-Length, hoặc số byte đã đọc khi body là chunked)
+	BytesSent     int64     // Số byte body response đã ghi
+
+	// FirstByteTime là thời gian từ đầu request tới khi header response được ghi,
+	// chỉ có trong entry response; ProcessTime - FirstByteTime là thời gian ghi body
+	FirstByteTime time.Duration
 }
 
 // ResponseWriter là wrapper cho gin.ResponseWriter để ghi lại response body
@@ -75,6 +123,7 @@ type ResponseWriter struct {
 	body        *bytes.Buffer
 	statusCode  int
 	wroteHeader bool
+	headerAt    time.Time
 }
 
 // Write ghi dữ liệu vào buffer và sau đó xuống response writer gốc
@@ -91,25 +140,25 @@ func (w *ResponseWriter) WriteHeader(code int) {
 	if !w.wroteHeader {
 		w.statusCode = code
 		w.wroteHeader = true
+		w.headerAt = time.Now()
 		w.ResponseWriter.WriteHeader(code)
 	}
 }
 
 // RecoveryMiddleware trả về middleware dùng để recover panic
-// và log lỗi ra hệ thống đồng thời trả về lỗi HTTP 500
+// và log lỗi ra hệ thống đồng thời trả về lỗi HTTP 500.
+// Request ID được xác định trước khi chạy các middleware phía sau,
+// nên lỗi luôn được log với cùng request ID của request.
 func RecoveryMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		requestID := ensureRequestID(c)
 		safe.SafeGo(func(ex error) {
 			if ex != nil {
-				requestID := c.GetString("requestID")
-				if requestID == "" {
-					requestID = uuid.NewString()
-				}
-
-				defaultLogger.LogError(requestID, ex)
+				loggerOf(c).LogError(requestID, newPanicError(c, requestID, ex))
+				flushDeferredLog(c, 500)
 
 				c.JSON(500, gin.H{
-					"message":    "Internal Server Error. Please try again later.",
+					"message":    Message(c, MessageInternalError),
 					"request_id": requestID,
 				})
 				c.Abort()
@@ -120,33 +169,98 @@ func RecoveryMiddleware() gin.HandlerFunc {
 	}
 }
 
-// LogRequestMiddleware trả về middleware để log thông tin request đầu vào
+// PanicError là lỗi được truyền cho Logger.LogError khi RecoveryMiddleware hoặc
+// GinRecoveryCompat recover panic, để logger/sink phân biệt panic với lỗi khác
+// (errors.As) và lấy stack trace cùng thông tin request
+type PanicError struct {
+	Err   error    // Lỗi mô tả giá trị panic
+	Stack []byte   // Stack trace tại thời điểm panic
+	Entry LogEntry // Thông tin request bị panic, status 500
+}
+
+// Error implements error, giữ nguyên nội dung lỗi panic
+func (e *PanicError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap trả về lỗi gốc
+func (e *PanicError) Unwrap() error {
+	return e.Err
+}
+
+// newPanicError tạo PanicError cho request đang xử lý; phải được gọi trong
+// lúc recover để stack trace còn chứa vị trí panic
+func newPanicError(c *gin.Context, requestID string, err error) *PanicError {
+	entry := LogEntry{
+		StatusCode:  500,
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		ClientIP:    c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		RequestID:   requestID,
+		Error:       err.Error(),
+		Labels:      labelsOf(c),
+		Fingerprint: Fingerprint(c),
+		DryRun:      IsDryRun(c),
+	}
+	if start := StartTime(c); !start.IsZero() {
+		entry.ProcessTime = time.Since(start)
+	}
+	return &PanicError{Err: err, Stack: debug.Stack(), Entry: entry}
+}
+
+// LogRequestMiddleware trả về middleware để log thông tin request đầu vào.
+// Nếu chain bị dừng (c.Abort) trước khi tới LogResponseMiddleware, middleware này
+// vẫn ghi metrics và log response với status cuối cùng cùng lý do dừng chain.
 func LogRequestMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		inFlightRequests.Add(1)
+		defer inFlightRequests.Add(-1)
 		start := time.Now()
-		c.Set("startTime", start)
-		requestID := uuid.New().String()
-		c.Set("requestID", requestID)
+		c.Set(ContextKeyStartTime, start)
+		requestID := ensureRequestID(c)
 
 		var requestBody []byte
-		if c.Request.Body != nil && !isMultipartForm(c.Request.Header.Get("Content-Type")) {
-			requestBody, _ = io.ReadAll(c.Request.Body)
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+		if c.Request.Body != nil && !bodyproc.IsMultipart(c.Request.Header.Get("Content-Type")) {
+			requestBody, c.Request.Body, _, _ = bodyproc.Capture(c.Request.Body, 0)
+			c.Set(ContextKeyRequestBytes, int64(len(requestBody)))
+		}
+
+		if currentNotFoundAggregator() != nil && isUnmatchedRoute(c) {
+			c.Next()
+			logUnreachedResponse(c, requestID, start)
+			return
 		}
 
 		entryReq := LogEntry{
 			StatusCode:  c.Writer.Status(),
 			Method:      c.Request.Method,
 			Path:        c.Request.URL.Path,
-			Request:     compactJSON(string(requestBody)),
+			Request:     logBody(string(requestBody)),
 			ProcessTime: time.Since(start),
 			ClientIP:    c.ClientIP(),
 			UserAgent:   c.Request.UserAgent(),
 			RequestID:   requestID,
+			Labels:      labelsOf(c),
+			Fingerprint: Fingerprint(c),
+			DryRun:      IsDryRun(c),
+		}
+		if LogForced(c) {
+			entryReq.Headers = formatLogHeaders(c.Request.Header)
+		}
+		if !LogSampled(c) {
+			// Hoãn quyết định bỏ log tới khi request kết thúc: nếu lỗi 5xx/panic,
+			// entry (kèm body đã buffer và header) vẫn được ghi lại
+			entryReq.Headers = formatLogHeaders(c.Request.Header)
+			c.Set(ContextKeyDeferredLog, &entryReq)
+			c.Next()
+			logUnreachedResponse(c, requestID, start)
+			return
 		}
-		defaultLogger.LogRequest(entryReq)
+		loggerOf(c).LogRequest(entryReq)
 
 		c.Next()
+		logUnreachedResponse(c, requestID, start)
 	}
 }
 
@@ -154,57 +268,163 @@ func LogRequestMiddleware() gin.HandlerFunc {
 // và ghi nhận các metrics liên quan đến request.
 func LogResponseMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		start := c.GetTime("startTime")
-		duration := time.Since(start)
-		requestID := c.GetString("requestID")
-		if requestID == "" {
-			requestID = uuid.New().String()
-			c.Set("requestID", requestID)
+		start := StartTime(c)
+		if start.IsZero() {
+			start = time.Now()
 		}
+		requestID := ensureRequestID(c)
 
 		bodyWriter := &ResponseWriter{
 			ResponseWriter: c.Writer,
 			body:           bytes.NewBufferString(""),
 		}
 		c.Writer = bodyWriter
+		c.Set(ContextKeyResponseLogged, true)
 
 		c.Next()
 
-		entryRes := LogEntry{
-			StatusCode:  bodyWriter.statusCode,
-			Method:      c.Request.Method,
-			Path:        c.Request.URL.Path,
-			Response:    compactJSON(bodyWriter.body.String()),
-			ProcessTime: duration,
-			ClientIP:    c.ClientIP(),
-			UserAgent:   c.Request.UserAgent(),
-			RequestID:   requestID,
+		var firstByte time.Duration
+		if bodyWriter.wroteHeader {
+			firstByte = bodyWriter.headerAt.Sub(start)
+		}
+		recordResponse(c, requestID, time.Since(start), firstByte, bodyWriter.statusCode, bodyWriter.wroteHeader, bodyWriter.body.String())
+	}
+}
+
+// recordResponse ghi metrics và log response của request với status cuối cùng.
+// Được gọi bởi LogResponseMiddleware, hoặc bởi LogRequestMiddleware khi chain bị
+// dừng trước khi tới LogResponseMiddleware (xem AbortWithReason).
+func recordResponse(c *gin.Context, requestID string, duration, firstByte time.Duration, statusCode int, wroteHeader bool, body string) {
+	status, clientAborted := recordRequestMetrics(c, duration, statusCode, wroteHeader)
+
+	if agg := currentNotFoundAggregator(); agg != nil && c.Writer.Status() == 404 && isUnmatchedRoute(c) {
+		agg.record(c.Request.URL.Path, c.ClientIP())
+		return
+	}
+	escalated := false
+	if !LogSampled(c) {
+		if status < 500 {
+			return
+		}
+		flushDeferredLog(c, status)
+		escalated = true
+	}
+
+	entryRes := LogEntry{
+		StatusCode:  status,
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		Response:    logBody(body),
+		ProcessTime: duration,
+		ClientIP:    c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		RequestID:   requestID,
+		RateLimit:   RateLimitDecision(c),
+		Labels:      labelsOf(c),
+		Fingerprint: Fingerprint(c),
+		DryRun:      IsDryRun(c),
+		ClientGone:  clientAborted,
+		Escalated:   escalated,
+
+		StartTime:     requestStart(c, duration),
+		URI:           c.Request.URL.RequestURI(),
+		Protocol:      c.Request.Proto,
+		Authority:     c.Request.Host,
+		XForwardedFor: c.Request.Header.Get("X-Forwarded-For"),
+		BytesReceived: requestBytes(c),
+		BytesSent:     int64(max(c.Writer.Size(), 0)),
+
+		FirstByteTime: firstByte,
+	}
+	if info, ok := Aborted(c); ok {
+		entryRes.AbortedBy = info.Middleware
+		entryRes.AbortReason = info.Reason
+	}
+	loggerOf(c).LogResponse(entryRes)
+}
+
+// requestStart trả về thời điểm bắt đầu của request, ước lượng từ duration nếu
+// LogRequestMiddleware không chạy
+func requestStart(c *gin.Context, duration time.Duration) time.Time {
+	if start := StartTime(c); !start.IsZero() {
+		return start
+	}
+	return time.Now().Add(-duration)
+}
+
+// requestBytes trả về kích thước body request: Content-Length nếu có, ngược lại
+// (chunked) là số byte LogRequestMiddleware đã đọc
+func requestBytes(c *gin.Context) int64 {
+	if c.Request.ContentLength >= 0 {
+		return c.Request.ContentLength
+	}
+	return c.GetInt64(ContextKeyRequestBytes)
+}
+
+// recordRequestMetrics ghi metrics của request đã kết thúc và trả về status
+// được ghi nhận. Khi client đã ngắt kết nối, disconnect luôn được đếm; status là
+// 499 nếu chưa có response nào được ghi hoặc response là 5xx (thường là lỗi
+// "context canceled" do chính việc ngắt kết nối), để không bị tính là lỗi server.
+func recordRequestMetrics(c *gin.Context, duration time.Duration, statusCode int, wroteHeader bool) (status int, clientAborted bool) {
+	m := metricsOf(c)
+	status = statusCode
+	clientAborted = ClientGone(c)
+	if clientAborted {
+		atomic.AddUint64(&m.ClientDisconnects, 1)
+		if !wroteHeader || statusCode >= 500 {
+			status = StatusClientClosedRequest
 		}
-		defaultLogger.LogResponse(entryRes)
+	}
 
-		// Ghi lại metrics
-		atomic.AddUint64(&metrics.TotalRequests, 1)
-		atomic.AddUint64(&metrics.TotalDuration, uint64(duration.Milliseconds()))
-		metrics.RecordRequest(c.Request.Method, bodyWriter.statusCode, duration)
+	atomic.AddUint64(&m.TotalRequests, 1)
+	atomic.AddUint64(&m.TotalDuration, uint64(duration.Milliseconds()))
+	m.RecordRequest(c.Request.Method, status, duration)
+	m.RecordRoute(c.Request.Method, c.FullPath())
+	if IsSelfTest(c) {
+		return status, clientAborted
 	}
+	emitRequestSample(RequestSample{
+		Method:     c.Request.Method,
+		Route:      c.FullPath(),
+		StatusCode: status,
+		Duration:   duration,
+		Labels:     labelsOf(c),
+	})
+	return status, clientAborted
 }
 
-// isMultipartForm kiểm tra xem content-type có phải multipart form
-func isMultipartForm(contentType string) bool {
-	return strings.HasPrefix(contentType, "multipart/form-data")
+// logUnreachedResponse ghi response cho request bị dừng chain trước khi tới
+// LogResponseMiddleware, để status cuối cùng và lý do vẫn xuất hiện trong log/metrics
+func logUnreachedResponse(c *gin.Context, requestID string, start time.Time) {
+	if !c.IsAborted() || c.GetBool(ContextKeyResponseLogged) {
+		return
+	}
+	recordResponse(c, requestID, time.Since(start), 0, c.Writer.Status(), c.Writer.Written(), "")
 }
 
-// compactJSON nhận chuỗi JSON và loại bỏ các khoảng trắng không cần thiết
-func compactJSON(data string) string {
-	if data == "" {
-		return ""
+// flushDeferredLog ghi entry request đã bị hoãn (xem LogSamplingMiddleware) với
+// status cuối cùng, mỗi request tối đa một lần. Trả về false nếu không có entry.
+func flushDeferredLog(c *gin.Context, status int) bool {
+	value, ok := c.Get(ContextKeyDeferredLog)
+	entry, _ := value.(*LogEntry)
+	if !ok || entry == nil {
+		return false
 	}
-	var compactedJSON bytes.Buffer
-	err := json.Compact(&compactedJSON, []byte(data))
-	if err != nil {
-		return data
+	c.Set(ContextKeyDeferredLog, (*LogEntry)(nil))
+
+	entry.StatusCode = status
+	entry.Escalated = true
+	loggerOf(c).LogRequest(*entry)
+	return true
+}
+
+// logBody compact body JSON và áp dụng logBodyHook nếu có
+func logBody(data string) string {
+	data = bodyproc.Compact(data)
+	if logBodyHook != nil {
+		data = logBodyHook(data)
 	}
-	return compactedJSON.String()
+	return data
 }
 
 // formatDuration định dạng duration thành chuỗi "x.xxms"
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// AbortInfo mô tả middleware đã dừng chain và lý do
+type AbortInfo struct {
+	Middleware string // Tên middleware, ví dụ "rate_limit", "auth"
+	Reason     string // Lý do ngắn gọn, ví dụ "limit exceeded: ip"
+	Status     int    // Status trả về cho client
+}
+
+// AbortWithReason dừng chain với status, ghi lại middleware và lý do vào context
+// (key ContextKeyAbort) để log và metrics phía ngoài ghi nhận được. body là JSON
+// trả về cho client; nil nghĩa là không có body. Middleware của ứng dụng (auth, ...)
+// nên dùng hàm này thay cho c.AbortWithStatusJSON.
+func AbortWithReason(c *gin.Context, status int, middleware, reason string, body interface{}) {
+	SetAbortReason(c, middleware, reason, status)
+	if body == nil {
+		c.AbortWithStatus(status)
+		return
+	}
+	c.AbortWithStatusJSON(status, body)
+}
+
+// SetAbortReason ghi lại middleware và lý do dừng chain mà không ghi response,
+// dùng khi middleware tự ghi response theo cách riêng. Chỉ lý do đầu tiên được giữ.
+func SetAbortReason(c *gin.Context, middleware, reason string, status int) {
+	if _, exists := c.Get(ContextKeyAbort); exists {
+		return
+	}
+	c.Set(ContextKeyAbort, AbortInfo{Middleware: middleware, Reason: reason, Status: status})
+}
+
+// Aborted trả về thông tin dừng chain của request hiện tại, nếu có
+func Aborted(c *gin.Context) (AbortInfo, bool) {
+	value, ok := c.Get(ContextKeyAbort)
+	if !ok {
+		return AbortInfo{}, false
+	}
+	info, ok := value.(AbortInfo)
+	return info, ok
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// acmeChallengePrefix là path của HTTP-01 challenge (RFC 8555)
+const acmeChallengePrefix = "/.well-known/acme-challenge/"
+
+// ACMEConfig cấu hình lấy và gia hạn certificate tự động qua ACME (mặc định
+// Let's Encrypt) với HTTP-01 challenge, dùng trong ServeConfig.ACME. Challenge
+// được phục vụ trên listener HTTP riêng chỉ với recovery và logging, nên không
+// bị các middleware chặn request của ứng dụng từ chối.
+type ACMEConfig struct {
+	// Domains là danh sách domain được phép xin certificate, bắt buộc; request
+	// với SNI khác bị từ chối để không bị lạm dụng hạn mức của CA
+	Domains []string
+	// CacheDir lưu account key và certificate giữa các lần restart, bắt buộc
+	CacheDir string
+	// Email liên hệ đăng ký với CA (thông báo hết hạn), có thể rỗng
+	Email string
+	// HTTPAddress là địa chỉ lắng nghe HTTP-01 challenge và redirect sang HTTPS,
+	// mặc định ":80"
+	HTTPAddress string
+	// DirectoryURL là ACME directory, mặc định Let's Encrypt production; dùng
+	// "https://acme-staging-v02.api.letsencrypt.org/directory" khi thử nghiệm
+	DirectoryURL string
+}
+
+// Validate kiểm tra tính hợp lệ của ACMEConfig
+func (c ACMEConfig) Validate() error {
+	var errs configErrors
+	if len(c.Domains) == 0 {
+		errs.addf("Domains is required")
+	}
+	for _, domain := range c.Domains {
+		if domain == "" || strings.ContainsAny(domain, "/:* ") {
+			errs.addf("Domains: %q is not a valid host name", domain)
+		}
+	}
+	if c.CacheDir == "" {
+		errs.addf("CacheDir is required, otherwise certificates are requested again on every restart")
+	}
+	return errs.err()
+}
+
+// newACMEManager tạo autocert.Manager theo cấu hình
+func newACMEManager(config ACMEConfig) *autocert.Manager {
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(config.Domains...),
+		Cache:      autocert.DirCache(config.CacheDir),
+		Email:      config.Email,
+	}
+	if config.DirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: config.DirectoryURL}
+	}
+	return manager
+}
+
+// acmeHTTPHandler trả về handler của listener HTTP: mọi request ngoài challenge
+// được redirect sang HTTPS. Challenge được phục vụ qua một engine riêng chỉ có
+// recovery và logging, không qua engine của ứng dụng: server xác thực của CA
+// phải tới được challenge dù ứng dụng đang maintenance, chặn theo IP, chặn bot
+// hay rate limit, và route của challenge không được xung đột với route
+// catch-all (ví dụ "/*path") của ứng dụng.
+func acmeHTTPHandler(manager *autocert.Manager) http.Handler {
+	redirect := manager.HTTPHandler(nil)
+	engine := gin.New()
+	engine.Use(RecoveryMiddleware(), LogRequestMiddleware(), LogResponseMiddleware())
+	engine.GET(acmeChallengePrefix+"*token", gin.WrapH(redirect))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, acmeChallengePrefix) {
+			engine.ServeHTTP(w, r)
+			return
+		}
+		redirect.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AfterSuccessHook là một side effect (domain event, webhook, ...) chạy sau khi
+// response thành công đã được gửi. ctx không bị huỷ khi request kết thúc.
+type AfterSuccessHook func(ctx context.Context) error
+
+// afterSuccessQueue là danh sách hook đã đăng ký của một request
+type afterSuccessQueue struct {
+	hooks []AfterSuccessHook
+	held  bool // Chờ TransactionMiddleware bên ngoài commit rồi mới chạy
+}
+
+// AfterSuccess đăng ký hook chạy sau khi response 2xx của request hiện tại được gửi.
+// Hook chỉ chạy khi AfterSuccessMiddleware được cài đặt và request không phải dry-run.
+func AfterSuccess(c *gin.Context, hook AfterSuccessHook) {
+	var queue *afterSuccessQueue
+	if v, ok := c.Get(ContextKeyAfterSuccess); ok {
+		queue, _ = v.(*afterSuccessQueue)
+	}
+	if queue == nil {
+		queue = &afterSuccessQueue{}
+		c.Set(ContextKeyAfterSuccess, queue)
+	}
+	queue.hooks = append(queue.hooks, hook)
+}
+
+// AfterSuccessMiddleware trả về middleware chạy các hook đăng ký qua AfterSuccess
+// khi response là 2xx. Response được flush trước, hook chạy trong goroutine riêng
+// nên không làm chậm response; panic trong hook được cô lập và log. Số hook
+// chạy/lỗi/panic được ghi vào metrics (key "after_success_hooks").
+//
+// Khi được cài bên trong TransactionMiddleware, status 2xx lúc này chỉ là response
+// đang bị giữ lại: hook chờ tới khi transaction commit thành công mới chạy, và bị
+// bỏ qua nếu transaction rollback hoặc commit lỗi. Hook của request dry-run (xem
+// DryRunMiddleware) luôn bị bỏ qua và được đếm vào "skipped", vì dry-run không
+// được phép gây side effect.
+func AfterSuccessMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, inTx := c.Get(ContextKeyTx)
+		c.Next()
+
+		v, ok := c.Get(ContextKeyAfterSuccess)
+		if !ok {
+			return
+		}
+		queue, ok := v.(*afterSuccessQueue)
+		if !ok || len(queue.hooks) == 0 {
+			return
+		}
+		status := c.Writer.Status()
+		if status < 200 || status >= 300 || IsDryRun(c) {
+			atomic.AddUint64(&metricsOf(c).HooksSkipped, uint64(len(queue.hooks)))
+			return
+		}
+
+		if inTx {
+			queue.held = true
+			return
+		}
+		startAfterSuccessHooks(c, queue.hooks)
+	}
+}
+
+// startAfterSuccessHooks flush response rồi chạy hooks trong goroutine riêng
+func startAfterSuccessHooks(c *gin.Context, hooks []AfterSuccessHook) {
+	c.Writer.Flush()
+
+	requestID := RequestID(c)
+	ctx := context.WithoutCancel(c.Request.Context())
+	logger, m := loggerOf(c), metricsOf(c)
+	go func() {
+		for _, hook := range hooks {
+			runAfterSuccessHook(ctx, logger, m, requestID, hook)
+		}
+	}()
+}
+
+// takeHeldAfterSuccessHooks lấy ra các hook đang chờ transaction commit
+func takeHeldAfterSuccessHooks(c *gin.Context) []AfterSuccessHook {
+	v, ok := c.Get(ContextKeyAfterSuccess)
+	if !ok {
+		return nil
+	}
+	queue, ok := v.(*afterSuccessQueue)
+	if !ok || !queue.held {
+		return nil
+	}
+	hooks := queue.hooks
+	queue.hooks, queue.held = nil, false
+	return hooks
+}
+
+// skipHeldAfterSuccessHooks bỏ các hook đang chờ khi transaction không commit
+func skipHeldAfterSuccessHooks(c *gin.Context) {
+	if hooks := takeHeldAfterSuccessHooks(c); len(hooks) > 0 {
+		atomic.AddUint64(&metricsOf(c).HooksSkipped, uint64(len(hooks)))
+	}
+}
+
+// runAfterSuccessHook chạy một hook với panic isolation
+func runAfterSuccessHook(ctx context.Context, logger Logger, m *Metrics, requestID string, hook AfterSuccessHook) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddUint64(&m.HooksPanicked, 1)
+			logger.LogError(requestID, fmt.Errorf("after success hook panic: %v\n%s", r, debug.Stack()))
+		}
+	}()
+
+	atomic.AddUint64(&m.HooksRun, 1)
+	if err := hook(ctx); err != nil {
+		atomic.AddUint64(&m.HooksFailed, 1)
+		logger.LogError(requestID, fmt.Errorf("after success hook: %w", err))
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAfterSuccessSkipsDryRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core := &Core{Logger: discardLogger{}, Metrics: NewMetrics()}
+	ran := make(chan struct{}, 2)
+
+	r := gin.New()
+	core.Attach(r, nil)
+	r.Use(DryRunMiddleware(), AfterSuccessMiddleware())
+	r.POST("/orders", func(c *gin.Context) {
+		AfterSuccess(c, func(context.Context) error {
+			ran <- struct{}{}
+			return nil
+		})
+		c.Status(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set("X-Dry-Run", "true")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if n := atomic.LoadUint64(&core.Metrics.HooksSkipped); n != 1 {
+		t.Fatalf("skipped hooks: got %d, want 1", n)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("hook of a normal request did not run")
+	}
+	select {
+	case <-ran:
+		t.Fatal("hook of the dry-run request ran")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	rtmetrics "runtime/metrics"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllocProfilingConfig cấu hình cho AllocProfilingMiddleware
+type AllocProfilingConfig struct {
+	SampleRate float64 // Tỷ lệ request được đo, trong (0, 1], mặc định 0.01
+	MaxRoutes  int     // Số route tối đa được theo dõi, mặc định 500
+}
+
+// Validate kiểm tra tính hợp lệ của AllocProfilingConfig
+func (c AllocProfilingConfig) Validate() error {
+	var errs configErrors
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		errs.addf("SampleRate must be within [0, 1], got %v", c.SampleRate)
+	}
+	if c.MaxRoutes < 0 {
+		errs.addf("MaxRoutes must not be negative, got %d", c.MaxRoutes)
+	}
+	return errs.err()
+}
+
+// RouteAllocations là ước lượng lượng cấp phát bộ nhớ của một route
+type RouteAllocations struct {
+	Route   string `json:"route"`
+	Samples uint64 `json:"samples"`
+	// AvgBytes/AvgObjects tính trên mọi mẫu, bao gồm cả cấp phát của request chạy song song
+	AvgBytes   uint64 `json:"avg_bytes"`
+	AvgObjects uint64 `json:"avg_objects"`
+	// Exclusive* chỉ tính các mẫu không có request nào khác chạy song song,
+	// chính xác hơn nhưng hiếm hơn khi tải cao
+	ExclusiveSamples    uint64 `json:"exclusive_samples"`
+	ExclusiveAvgBytes   uint64 `json:"exclusive_avg_bytes"`
+	ExclusiveAvgObjects uint64 `json:"exclusive_avg_objects"`
+}
+
+// routeAllocStat là tổng cộng dồn của các mẫu của một route
+type routeAllocStat struct {
+	samples, bytes, objects                            uint64
+	exclusiveSamples, exclusiveBytes, exclusiveObjects uint64
+}
+
+var (
+	allocMu    sync.Mutex
+	allocStats = make(map[string]*routeAllocStat)
+
+	allocInFlight atomic.Int64
+	allocStarted  atomic.Uint64
+)
+
+// allocSampleNames là các metric runtime đếm cấp phát heap tích luỹ
+var allocSampleNames = []string{"/gc/heap/allocs:bytes", "/gc/heap/allocs:objects"}
+
+// AllocProfilingMiddleware trả về middleware chẩn đoán (opt-in) đo lượng cấp phát
+// heap quanh một phần nhỏ request và tổng hợp theo route, xem qua AllocationProfile
+// hoặc endpoint /debug/allocations của RegisterOpsEndpoints. Số đo là delta toàn
+// process (runtime/metrics, không stop-the-world như ReadMemStats) nên chỉ là
+// ước lượng khi có request chạy song song; các mẫu "exclusive" loại bỏ nhiễu đó.
+// Runtime cộng dồn cấp phát nhỏ theo từng span, nên handler cấp phát rất ít có thể hiện 0.
+func AllocProfilingMiddleware(config AllocProfilingConfig) gin.HandlerFunc {
+	mustValidate("AllocProfiling", config)
+	if config.SampleRate == 0 {
+		config.SampleRate = 0.01
+	}
+	if config.MaxRoutes == 0 {
+		config.MaxRoutes = 500
+	}
+
+	return func(c *gin.Context) {
+		inFlight := allocInFlight.Add(1)
+		defer allocInFlight.Add(-1)
+		started := allocStarted.Add(1)
+
+		if rand.Float64() >= config.SampleRate {
+			c.Next()
+			return
+		}
+
+		before := readAllocSamples()
+		c.Next()
+		after := readAllocSamples()
+
+		exclusive := inFlight == 1 && allocStarted.Load() == started
+		recordAllocSample(config.MaxRoutes, c.Request.Method+" "+c.FullPath(),
+			after[0]-before[0], after[1]-before[1], exclusive)
+	}
+}
+
+// readAllocSamples đọc tổng số byte và object đã cấp phát trên heap
+func readAllocSamples() [2]uint64 {
+	samples := make([]rtmetrics.Sample, len(allocSampleNames))
+	for i, name := range allocSampleNames {
+		samples[i].Name = name
+	}
+	rtmetrics.Read(samples)
+
+	var result [2]uint64
+	for i, s := range samples {
+		if s.Value.Kind() == rtmetrics.KindUint64 {
+			result[i] = s.Value.Uint64()
+		}
+	}
+	return result
+}
+
+// recordAllocSample cộng dồn một mẫu vào thống kê của route
+func recordAllocSample(maxRoutes int, route string, bytes, objects uint64, exclusive bool) {
+	allocMu.Lock()
+	defer allocMu.Unlock()
+	stat, ok := allocStats[route]
+	if !ok {
+		if len(allocStats) >= maxRoutes {
+			return
+		}
+		stat = &routeAllocStat{}
+		allocStats[route] = stat
+	}
+	stat.samples++
+	stat.bytes += bytes
+	stat.objects += objects
+	if exclusive {
+		stat.exclusiveSamples++
+		stat.exclusiveBytes += bytes
+		stat.exclusiveObjects += objects
+	}
+}
+
+// AllocationProfile trả về ước lượng cấp phát theo route, route tốn nhiều byte nhất trước
+func AllocationProfile() []RouteAllocations {
+	allocMu.Lock()
+	result := make([]RouteAllocations, 0, len(allocStats))
+	for route, s := range allocStats {
+		entry := RouteAllocations{
+			Route:            route,
+			Samples:          s.samples,
+			AvgBytes:         s.bytes / s.samples,
+			AvgObjects:       s.objects / s.samples,
+			ExclusiveSamples: s.exclusiveSamples,
+		}
+		if s.exclusiveSamples > 0 {
+			entry.ExclusiveAvgBytes = s.exclusiveBytes / s.exclusiveSamples
+			entry.ExclusiveAvgObjects = s.exclusiveObjects / s.exclusiveSamples
+		}
+		result = append(result, entry)
+	}
+	allocMu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].AvgBytes > result[j].AvgBytes })
+	return result
+}
+
+// ResetAllocationProfile xoá các mẫu đã thu thập
+func ResetAllocationProfile() {
+	allocMu.Lock()
+	allocStats = make(map[string]*routeAllocStat)
+	allocMu.Unlock()
+}
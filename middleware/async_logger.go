@@ -0,0 +1,259 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what AsyncLogger does when its queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowDropNew discards the entry being logged, keeping the queued backlog
+	OverflowDropNew OverflowPolicy = iota
+	// OverflowDropOld discards the oldest queued entry to make room for the new one
+	OverflowDropOld
+	// OverflowBlock waits up to BlockTimeout for room, then discards the new entry
+	OverflowBlock
+)
+
+// String returns the policy name used in metrics and warnings
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropNew:
+		return "drop-new"
+	case OverflowDropOld:
+		return "drop-old"
+	case OverflowBlock:
+		return "block"
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+}
+
+// AsyncLoggerConfig configures NewAsyncLogger
+type AsyncLoggerConfig struct {
+	QueueSize    int            // Queue capacity, default 1024
+	Overflow     OverflowPolicy // Behavior when the queue is full, default OverflowDropNew
+	BlockTimeout time.Duration  // Max wait with OverflowBlock, default 50ms
+	WarnInterval time.Duration  // Interval of the summarized drop warning, default 10s
+}
+
+// Validate checks the AsyncLoggerConfig for invalid values
+func (c AsyncLoggerConfig) Validate() error {
+	var errs configErrors
+	if c.QueueSize < 0 {
+		errs.addf("QueueSize must not be negative, got %d", c.QueueSize)
+	}
+	if c.Overflow < OverflowDropNew || c.Overflow > OverflowBlock {
+		errs.addf("Overflow: unknown policy %d", c.Overflow)
+	}
+	if c.BlockTimeout < 0 || c.WarnInterval < 0 {
+		errs.addf("BlockTimeout/WarnInterval must not be negative")
+	}
+	if c.BlockTimeout > 0 && c.Overflow != OverflowBlock {
+		errs.addf("BlockTimeout is only used with OverflowBlock, got policy %s", c.Overflow)
+	}
+	return errs.err()
+}
+
+// AsyncLogger queues entries and writes them to a backend Logger from a
+// background goroutine, so a slow backend only costs a channel send on the
+// request path. When the queue is full the configured OverflowPolicy applies;
+// drops are counted and summarized in a periodic warning.
+type AsyncLogger struct {
+	backend Logger
+	config  AsyncLoggerConfig
+	queue   chan LogRecord
+
+	enqueued     atomic.Uint64
+	written      atomic.Uint64
+	dropped      atomic.Uint64
+	droppedSince atomic.Uint64
+
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewAsyncLogger starts an AsyncLogger writing to backend. Call Close on
+// shutdown to flush the queue.
+func NewAsyncLogger(backend Logger, config AsyncLoggerConfig) *AsyncLogger {
+	mustValidate("AsyncLogger", config)
+	if config.QueueSize == 0 {
+		config.QueueSize = 1024
+	}
+	if config.BlockTimeout == 0 {
+		config.BlockTimeout = 50 * time.Millisecond
+	}
+	if config.WarnInterval == 0 {
+		config.WarnInterval = 10 * time.Second
+	}
+
+	l := &AsyncLogger{
+		backend: backend,
+		config:  config,
+		queue:   make(chan LogRecord, config.QueueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// LogRequest implements Logger interface for AsyncLogger
+func (l *AsyncLogger) LogRequest(entry LogEntry) {
+	l.enqueue(LogRecord{Kind: LogKindRequest, Entry: &entry})
+}
+
+// LogResponse implements Logger interface for AsyncLogger
+func (l *AsyncLogger) LogResponse(entry LogEntry) {
+	l.enqueue(LogRecord{Kind: LogKindResponse, Entry: &entry})
+}
+
+// LogError implements Logger interface for AsyncLogger
+func (l *AsyncLogger) LogError(requestID string, err error) {
+	l.enqueue(LogRecord{Kind: LogKindError, RequestID: requestID, Err: err})
+}
+
+// LogMessage implements MessageLogger interface for AsyncLogger
+func (l *AsyncLogger) LogMessage(message string) {
+	l.enqueue(LogRecord{Kind: LogKindMessage, Message: message})
+}
+
+// LoggerStats implements LoggerStats interface for AsyncLogger
+func (l *AsyncLogger) LoggerStats() map[string]interface{} {
+	stats := map[string]interface{}{
+		"queue_depth":    len(l.queue),
+		"queue_capacity": cap(l.queue),
+		"overflow":       l.config.Overflow.String(),
+		"enqueued":       l.enqueued.Load(),
+		"written":        l.written.Load(),
+		"dropped":        l.dropped.Load(),
+	}
+	if s, ok := l.backend.(LoggerStats); ok {
+		stats["backend"] = s.LoggerStats()
+	}
+	return stats
+}
+
+// Close stops accepting entries and waits until the queue is flushed or ctx is done
+func (l *AsyncLogger) Close(ctx context.Context) error {
+	l.closeOnce.Do(func() { close(l.done) })
+	select {
+	case <-l.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueue adds a record to the queue applying the overflow policy
+func (l *AsyncLogger) enqueue(record LogRecord) {
+	select {
+	case <-l.done:
+		l.drop()
+		return
+	default:
+	}
+
+	select {
+	case l.queue <- record:
+		l.enqueued.Add(1)
+		return
+	default:
+	}
+
+	switch l.config.Overflow {
+	case OverflowDropOld:
+		for {
+			select {
+			case l.queue <- record:
+				l.enqueued.Add(1)
+				return
+			default:
+			}
+			select {
+			case <-l.queue:
+				l.drop()
+			default:
+			}
+		}
+	case OverflowBlock:
+		timer := time.NewTimer(l.config.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case l.queue <- record:
+			l.enqueued.Add(1)
+		case <-timer.C:
+			l.drop()
+		}
+	default:
+		l.drop()
+	}
+}
+
+// drop counts a discarded record
+func (l *AsyncLogger) drop() {
+	l.dropped.Add(1)
+	l.droppedSince.Add(1)
+}
+
+// run writes queued records to the backend and emits the drop warning;
+// on Close it flushes what is left in the queue
+func (l *AsyncLogger) run() {
+	defer close(l.stopped)
+	ticker := time.NewTicker(l.config.WarnInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case record := <-l.queue:
+			l.write(record)
+		case <-ticker.C:
+			l.warnDrops()
+		case <-l.done:
+			for {
+				select {
+				case record := <-l.queue:
+					l.write(record)
+				default:
+					l.warnDrops()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write delivers a record to the backend, isolating panics
+func (l *AsyncLogger) write(record LogRecord) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "async logger: backend panicked: %v\n", r)
+		}
+	}()
+	if err := dispatchLog(l.backend, record); err != nil {
+		fmt.Fprintf(os.Stderr, "async logger: %v\n", err)
+		return
+	}
+	l.written.Add(1)
+}
+
+// warnDrops writes one summarized warning for the records dropped since the last warning
+func (l *AsyncLogger) warnDrops() {
+	n := l.droppedSince.Swap(0)
+	if n == 0 {
+		return
+	}
+	message := fmt.Sprintf("[LOGGER] dropped %d log entries since the last report (queue %d/%d, policy %s)",
+		n, len(l.queue), cap(l.queue), l.config.Overflow)
+	if ml, ok := l.backend.(MessageLogger); ok {
+		ml.LogMessage(message)
+		return
+	}
+	fmt.Fprintln(os.Stderr, message)
+}
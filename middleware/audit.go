@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditChange là một thay đổi của resource tại một đường dẫn field
+type AuditChange struct {
+	Path   string      `json:"path"`             // Ví dụ "address.city" hoặc "items[0].qty"
+	Before interface{} `json:"before,omitempty"` // Giá trị trước, nil nếu field mới được thêm
+	After  interface{} `json:"after,omitempty"`  // Giá trị sau, nil nếu field bị xoá
+}
+
+// AuditRecord là bản ghi audit của một request thay đổi dữ liệu
+type AuditRecord struct {
+	Time       time.Time     `json:"time"`
+	RequestID  string        `json:"request_id"`
+	Actor      string        `json:"actor,omitempty"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	StatusCode int           `json:"status_code"`
+	Changes    []AuditChange `json:"changes"`
+}
+
+// AuditSink lưu trữ các bản ghi audit
+type AuditSink interface {
+	WriteAudit(record AuditRecord) error
+}
+
+// AuditSinkFunc là adapter cho phép dùng function làm AuditSink
+type AuditSinkFunc func(record AuditRecord) error
+
+// WriteAudit implements AuditSink
+func (f AuditSinkFunc) WriteAudit(record AuditRecord) error {
+	return f(record)
+}
+
+// AuditConfig cấu hình cho AuditMiddleware
+type AuditConfig struct {
+	Sink      AuditSink                   // Nơi lưu bản ghi, mặc định ghi JSON qua logger
+	ActorFunc func(c *gin.Context) string // Trả về người thực hiện (user ID, service, ...)
+}
+
+// Validate kiểm tra tính hợp lệ của AuditConfig
+func (c AuditConfig) Validate() error {
+	var errs configErrors
+	if f, ok := c.Sink.(AuditSinkFunc); ok && f == nil {
+		errs.addf("Sink: AuditSinkFunc is nil")
+	}
+	return errs.err()
+}
+
+// auditState lưu biểu diễn trước/sau của resource trong một request
+type auditState struct {
+	before, after       interface{}
+	hasBefore, hasAfter bool
+
+	held func() // Ghi bản ghi đang chờ TransactionMiddleware bên ngoài commit
+}
+
+// AuditBefore đăng ký biểu diễn của resource trước khi bị thay đổi
+func AuditBefore(c *gin.Context, resource interface{}) {
+	state := auditStateOf(c)
+	state.before, state.hasBefore = resource, true
+}
+
+// AuditAfter đăng ký biểu diễn của resource sau khi bị thay đổi
+func AuditAfter(c *gin.Context, resource interface{}) {
+	state := auditStateOf(c)
+	state.after, state.hasAfter = resource, true
+}
+
+// auditStateOf trả về auditState của request, tạo mới nếu chưa có
+func auditStateOf(c *gin.Context) *auditState {
+	if v, ok := c.Get(ContextKeyAudit); ok {
+		if state, ok := v.(*auditState); ok {
+			return state
+		}
+	}
+	state := &auditState{}
+	c.Set(ContextKeyAudit, state)
+	return state
+}
+
+// AuditMiddleware trả về middleware ghi bản ghi audit cho các request mà handler
+// đã đăng ký trạng thái trước/sau (AuditBefore/AuditAfter). Bản ghi gồm diff có
+// cấu trúc theo từng field, người thực hiện và request ID.
+//
+// Chỉ thay đổi thực sự được áp dụng mới được ghi: request dry-run, response không
+// phải 2xx hoặc có c.Errors (các trường hợp TransactionMiddleware rollback) bị bỏ
+// qua. Khi được cài bên trong TransactionMiddleware, bản ghi chờ tới khi
+// transaction commit thành công mới được ghi.
+//
+// Panic nếu config không hợp lệ (xem AuditConfig.Validate).
+func AuditMiddleware(config AuditConfig) gin.HandlerFunc {
+	mustValidate("Audit", config)
+	sink := config.Sink
+	if sink == nil {
+		sink = AuditSinkFunc(func(record AuditRecord) error {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			logMessage("[AUDIT] %s", data)
+			return nil
+		})
+	}
+
+	return func(c *gin.Context) {
+		_, inTx := c.Get(ContextKeyTx)
+		c.Next()
+
+		v, ok := c.Get(ContextKeyAudit)
+		if !ok {
+			return
+		}
+		state, ok := v.(*auditState)
+		if !ok || (!state.hasBefore && !state.hasAfter) {
+			return
+		}
+		status := c.Writer.Status()
+		if status < 200 || status >= 300 || len(c.Errors) > 0 || IsDryRun(c) {
+			return
+		}
+
+		changes, err := diffResources(state.before, state.after)
+		if err != nil {
+			loggerOf(c).LogError(RequestID(c), fmt.Errorf("audit diff: %w", err))
+			return
+		}
+
+		record := AuditRecord{
+			Time:       time.Now(),
+			RequestID:  RequestID(c),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			StatusCode: status,
+			Changes:    changes,
+		}
+		if config.ActorFunc != nil {
+			record.Actor = config.ActorFunc(c)
+		}
+		logger := loggerOf(c)
+		write := func() {
+			if err := sink.WriteAudit(record); err != nil {
+				logger.LogError(record.RequestID, fmt.Errorf("audit sink: %w", err))
+			}
+		}
+		if inTx {
+			state.held = write
+			return
+		}
+		write()
+	}
+}
+
+// writeHeldAudit ghi bản ghi audit đang chờ sau khi transaction commit thành công
+func writeHeldAudit(c *gin.Context) {
+	v, ok := c.Get(ContextKeyAudit)
+	if !ok {
+		return
+	}
+	if state, ok := v.(*auditState); ok && state.held != nil {
+		write := state.held
+		state.held = nil
+		write()
+	}
+}
+
+// diffResources so sánh hai resource qua biểu diễn JSON của chúng
+func diffResources(before, after interface{}) ([]AuditChange, error) {
+	b, err := normalizeJSON(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := normalizeJSON(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := []AuditChange{}
+	diffValues("", b, a, &changes)
+	return changes, nil
+}
+
+// normalizeJSON chuyển một giá trị bất kỳ về dạng map/slice/scalar của encoding/json
+func normalizeJSON(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// diffValues so sánh đệ quy hai giá trị JSON và ghi các thay đổi
+func diffValues(path string, before, after interface{}, changes *[]AuditChange) {
+	bm, bIsMap := before.(map[string]interface{})
+	am, aIsMap := after.(map[string]interface{})
+	if bIsMap && aIsMap {
+		keys := make(map[string]bool, len(bm)+len(am))
+		for k := range bm {
+			keys[k] = true
+		}
+		for k := range am {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			diffValues(child, bm[k], am[k], changes)
+		}
+		return
+	}
+
+	bs, bIsSlice := before.([]interface{})
+	as, aIsSlice := after.([]interface{})
+	if bIsSlice && aIsSlice {
+		for i := 0; i < max(len(bs), len(as)); i++ {
+			var bv, av interface{}
+			if i < len(bs) {
+				bv = bs[i]
+			}
+			if i < len(as) {
+				av = as[i]
+			}
+			diffValues(path+"["+strconv.Itoa(i)+"]", bv, av, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, AuditChange{Path: path, Before: before, After: after})
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeTx là Tx ghi lại kết quả, commitErr làm Commit thất bại
+type fakeTx struct {
+	commitErr             error
+	committed, rolledBack bool
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.committed = tx.commitErr == nil
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+// auditServer là engine có handler PUT /orders đăng ký audit trước/sau và trả về status
+type auditServer struct {
+	engine  *gin.Engine
+	records []AuditRecord
+	status  int
+}
+
+func newAuditServer(handlers ...gin.HandlerFunc) *auditServer {
+	gin.SetMode(gin.TestMode)
+	s := &auditServer{engine: gin.New(), status: http.StatusOK}
+	(&Core{Logger: discardLogger{}, Metrics: NewMetrics()}).Attach(s.engine, nil)
+	s.engine.Use(DryRunMiddleware())
+	s.engine.Use(handlers...)
+	s.engine.Use(AuditMiddleware(AuditConfig{Sink: AuditSinkFunc(func(r AuditRecord) error {
+		s.records = append(s.records, r)
+		return nil
+	})}))
+	s.engine.PUT("/orders", func(c *gin.Context) {
+		AuditBefore(c, map[string]interface{}{"qty": 1})
+		AuditAfter(c, map[string]interface{}{"qty": 2})
+		c.JSON(s.status, gin.H{"ok": true})
+	})
+	return s
+}
+
+func (s *auditServer) serve(dryRun bool) int {
+	req := httptest.NewRequest(http.MethodPut, "/orders", nil)
+	if dryRun {
+		req.Header.Set("X-Dry-Run", "1")
+	}
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAuditRecordsAppliedChanges(t *testing.T) {
+	s := newAuditServer()
+	s.serve(false)
+	if len(s.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(s.records))
+	}
+	changes := s.records[0].Changes
+	if len(changes) != 1 || changes[0].Path != "qty" || changes[0].Before != float64(1) || changes[0].After != float64(2) {
+		t.Fatalf("changes: got %+v, want qty 1 -> 2", changes)
+	}
+}
+
+func TestAuditSkipsChangesThatDidNotHappen(t *testing.T) {
+	s := newAuditServer()
+	s.serve(true)
+	s.status = http.StatusConflict
+	s.serve(false)
+	if len(s.records) != 0 {
+		t.Fatalf("got %d records for dry-run and 409 requests, want 0", len(s.records))
+	}
+}
+
+func TestAuditInsideTransactionWaitsForCommit(t *testing.T) {
+	tx := &fakeTx{commitErr: errors.New("serialization failure")}
+	starter := TxStarterFunc(func(context.Context) (Tx, error) { return tx, nil })
+	s := newAuditServer(TransactionMiddleware(starter))
+
+	if code := s.serve(false); code != http.StatusInternalServerError || len(s.records) != 0 {
+		t.Fatalf("commit failure: got %d with %d records, want 500 and no record", code, len(s.records))
+	}
+	tx.commitErr = nil
+	if code := s.serve(false); code != http.StatusOK || len(s.records) != 1 || !tx.committed {
+		t.Fatalf("commit: got %d with %d records, want 200 and 1 record", code, len(s.records))
+	}
+}
@@ -0,0 +1,331 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kimxuanhong/go-middleware/store"
+)
+
+// BotClass phân loại client theo user agent và kết quả xác thực
+type BotClass string
+
+const (
+	BotClassHuman        BotClass = "human"        // Không nhận diện được là bot
+	BotClassVerified     BotClass = "verified"     // Crawler đã được xác thực bằng reverse DNS
+	BotClassKnown        BotClass = "known"        // Bot đã biết nhưng không có cách xác thực (preview mạng xã hội, ...)
+	BotClassImpersonator BotClass = "impersonator" // Tự nhận là crawler xác thực được nhưng xác thực thất bại
+	BotClassUnverified   BotClass = "unverified"   // Tự nhận là crawler xác thực được nhưng tra DNS lỗi, chưa rõ thật giả
+	BotClassGeneric      BotClass = "generic"      // User agent mang dấu hiệu bot/scraper chung chung
+)
+
+// BotPolicy là hành động áp dụng cho một BotClass
+type BotPolicy int
+
+const (
+	BotAllow    BotPolicy = iota // Cho đi tiếp
+	BotThrottle                  // Giới hạn qua BotConfig.Limiter (mặc định defaultBotLimit), trả về 429 khi vượt
+	BotBlock                     // Từ chối với 403
+)
+
+// KnownBot mô tả một bot nhận diện qua user agent
+type KnownBot struct {
+	Name      string   // Tên bot, ví dụ "googlebot"
+	UserAgent string   // Chuỗi con (không phân biệt hoa thường) trong user agent
+	Domains   []string // Domain hợp lệ của reverse DNS; rỗng nghĩa là không xác thực được
+}
+
+// DefaultKnownBots là danh sách bot mặc định, Googlebot và Bingbot được xác thực
+// bằng reverse DNS theo hướng dẫn của Google/Microsoft
+var DefaultKnownBots = []KnownBot{
+	{Name: "googlebot", UserAgent: "googlebot", Domains: []string{"googlebot.com", "google.com"}},
+	{Name: "bingbot", UserAgent: "bingbot", Domains: []string{"search.msn.com"}},
+	{Name: "facebook", UserAgent: "facebookexternalhit"},
+	{Name: "twitter", UserAgent: "twitterbot"},
+	{Name: "slack", UserAgent: "slackbot"},
+	{Name: "linkedin", UserAgent: "linkedinbot"},
+}
+
+// DefaultGenericBotPatterns là các chuỗi con trong user agent của bot/scraper chung chung
+var DefaultGenericBotPatterns = []string{
+	"bot", "crawl", "spider", "scrapy", "curl/", "wget/", "python-requests", "go-http-client", "httpclient", "headless",
+}
+
+// BotResolver là các phép tra DNS cần cho việc xác thực crawler,
+// net.DefaultResolver implement interface này
+type BotResolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// BotConfig cấu hình cho BotMiddleware
+type BotConfig struct {
+	Bots            []KnownBot             // Mặc định DefaultKnownBots
+	GenericPatterns []string               // Mặc định DefaultGenericBotPatterns
+	Policies        map[BotClass]BotPolicy // Ghi đè policy mặc định theo class
+	Limiter         RateLimiter            // Limiter cho BotThrottle, key là "<class>:<ip>"; mặc định token bucket defaultBotLimit
+	Resolver        BotResolver            // Mặc định net.DefaultResolver
+	Cache           store.Store            // Cache kết quả xác thực, mặc định MemoryStore 10000 entry
+	CacheTTL        time.Duration          // Thời gian cache kết quả xác thực, mặc định 1 giờ
+	LookupTimeout   time.Duration          // Timeout của mỗi lần xác thực DNS, mặc định 2 giây
+}
+
+// defaultBotPolicies giữ traffic SEO, chặn bot giả mạo và giới hạn scraper
+var defaultBotPolicies = map[BotClass]BotPolicy{
+	BotClassHuman:        BotAllow,
+	BotClassVerified:     BotAllow,
+	BotClassKnown:        BotAllow,
+	BotClassImpersonator: BotBlock,
+	BotClassUnverified:   BotThrottle,
+	BotClassGeneric:      BotThrottle,
+}
+
+// defaultBotRate và defaultBotBurst là giới hạn mặc định của BotThrottle cho mỗi
+// class và IP khi BotConfig.Limiter không được cấu hình
+const (
+	defaultBotRate  = 1.0 // request/giây
+	defaultBotBurst = 10
+)
+
+// Validate kiểm tra tính hợp lệ của BotConfig
+func (c BotConfig) Validate() error {
+	var errs configErrors
+	for class, policy := range c.Policies {
+		if policy < BotAllow || policy > BotBlock {
+			errs.addf("Policies[%q]: unknown policy %d", class, policy)
+		}
+	}
+	for i, bot := range c.Bots {
+		if bot.Name == "" || bot.UserAgent == "" {
+			errs.addf("Bots[%d]: Name and UserAgent are required", i)
+		}
+	}
+	if c.CacheTTL < 0 || c.LookupTimeout < 0 {
+		errs.addf("CacheTTL/LookupTimeout must not be negative")
+	}
+	return errs.err()
+}
+
+// policies trả về policy mặc định đã được ghi đè bởi c.Policies
+func (c BotConfig) policies() map[BotClass]BotPolicy {
+	policies := make(map[BotClass]BotPolicy, len(defaultBotPolicies))
+	for class, policy := range defaultBotPolicies {
+		policies[class] = policy
+	}
+	for class, policy := range c.Policies {
+		policies[class] = policy
+	}
+	return policies
+}
+
+// BotDetection là kết quả nhận diện bot của một request
+type BotDetection struct {
+	Name  string   // Tên bot (rỗng với human/generic)
+	Class BotClass // Phân loại
+}
+
+// DetectedBot trả về kết quả nhận diện của BotMiddleware cho request hiện tại
+func DetectedBot(c *gin.Context) (BotDetection, bool) {
+	value, ok := c.Get(ContextKeyBot)
+	if !ok {
+		return BotDetection{}, false
+	}
+	detection, ok := value.(BotDetection)
+	return detection, ok
+}
+
+// BotMiddleware trả về middleware nhận diện crawler/bot và áp dụng policy theo class.
+// Crawler có Domains (Googlebot, Bingbot) được xác thực bằng reverse DNS kèm tra
+// ngược để chống giả mạo user agent; kết quả được cache. Tỷ lệ traffic theo class
+// nằm trong metrics dưới key "bots".
+func BotMiddleware(config BotConfig) gin.HandlerFunc {
+	mustValidate("Bot", config)
+	if config.Bots == nil {
+		config.Bots = DefaultKnownBots
+	}
+	if config.GenericPatterns == nil {
+		config.GenericPatterns = DefaultGenericBotPatterns
+	}
+	if config.Resolver == nil {
+		config.Resolver = net.DefaultResolver
+	}
+	if config.Cache == nil {
+		config.Cache = store.NewMemoryStore(store.MemoryConfig{MaxEntries: 10000})
+	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = time.Hour
+	}
+	if config.LookupTimeout == 0 {
+		config.LookupTimeout = 2 * time.Second
+	}
+	policies := config.policies()
+	if config.Limiter == nil {
+		// Policy mặc định throttle bot chưa xác thực và scraper, nên luôn cần
+		// limiter; không có limiter thì BotThrottle chẳng khác gì BotAllow
+		config.Limiter = NewTokenBucketLimiter(defaultBotRate, defaultBotBurst)
+	}
+
+	return func(c *gin.Context) {
+		detection := classifyBot(c, config)
+		c.Set(ContextKeyBot, detection)
+		metricsOf(c).RecordBot(detection.Class)
+
+		switch policies[detection.Class] {
+		case BotBlock:
+			AbortWithReason(c, 403, "bot", "blocked bot class: "+string(detection.Class), gin.H{
+				"message": Message(c, MessageForbidden),
+			})
+			return
+		case BotThrottle:
+			result := allowRateLimit(c, config.Limiter, string(detection.Class)+":"+c.ClientIP())
+			if !result.Allowed {
+				c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.Reset)))
+				AbortWithReason(c, 429, "bot", "throttled bot class: "+string(detection.Class), gin.H{
+					"message": Message(c, MessageTooManyRequests),
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// classifyBot nhận diện bot từ user agent và xác thực crawler nếu cần
+func classifyBot(c *gin.Context, config BotConfig) BotDetection {
+	ua := strings.ToLower(c.Request.UserAgent())
+	for _, bot := range config.Bots {
+		if !strings.Contains(ua, strings.ToLower(bot.UserAgent)) {
+			continue
+		}
+		if len(bot.Domains) == 0 {
+			return BotDetection{Name: bot.Name, Class: BotClassKnown}
+		}
+		verified, err := verifyBot(c.Request.Context(), config, c.ClientIP(), bot)
+		switch {
+		case err != nil:
+			// Không xác thực được do lỗi DNS: không chặn như giả mạo để tránh chặn nhầm
+			// crawler thật, nhưng cũng không cho qua như bot đã xác thực
+			return BotDetection{Name: bot.Name, Class: BotClassUnverified}
+		case verified:
+			return BotDetection{Name: bot.Name, Class: BotClassVerified}
+		}
+		return BotDetection{Name: bot.Name, Class: BotClassImpersonator}
+	}
+
+	if ua == "" {
+		return BotDetection{Class: BotClassGeneric}
+	}
+	for _, pattern := range config.GenericPatterns {
+		if strings.Contains(ua, pattern) {
+			return BotDetection{Class: BotClassGeneric}
+		}
+	}
+	return BotDetection{Class: BotClassHuman}
+}
+
+// verifyBot xác thực IP thuộc về bot: reverse DNS phải trỏ về một trong các
+// domain của bot và forward DNS của hostname đó phải chứa lại IP ban đầu.
+// Lỗi DNS tạm thời được trả về và không được cache để lần sau thử lại.
+func verifyBot(ctx context.Context, config BotConfig, ip string, bot KnownBot) (bool, error) {
+	cacheKey := "bot-verify:" + bot.Name + ":" + ip
+	if value, ok, err := config.Cache.Get(ctx, cacheKey); err == nil && ok {
+		return string(value) == "1", nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.LookupTimeout)
+	defer cancel()
+
+	verified, err := lookupBot(ctx, config.Resolver, ip, bot.Domains)
+	if err != nil {
+		return false, err
+	}
+	value := []byte("0")
+	if verified {
+		value = []byte("1")
+	}
+	_ = config.Cache.Set(ctx, cacheKey, value, config.CacheTTL)
+	return verified, nil
+}
+
+// lookupBot thực hiện reverse DNS và forward-confirm cho ip. Chỉ "không tìm
+// thấy" mới là kết quả xác định; lỗi DNS khác (kể cả ở bước forward) được trả
+// về để không bị cache thành giả mạo.
+func lookupBot(ctx context.Context, resolver BotResolver, ip string, domains []string) (bool, error) {
+	names, err := resolver.LookupAddr(ctx, ip)
+	if err != nil {
+		if isDNSNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	var lookupErr error
+	for _, name := range names {
+		host := strings.TrimSuffix(strings.ToLower(name), ".")
+		if !hasDomainSuffix(host, domains) {
+			continue
+		}
+		addrs, err := resolver.LookupHost(ctx, host)
+		if err != nil {
+			if !isDNSNotFound(err) {
+				lookupErr = err
+			}
+			continue
+		}
+		for _, addr := range addrs {
+			if addr == ip {
+				return true, nil
+			}
+		}
+	}
+	return false, lookupErr
+}
+
+// isDNSNotFound kiểm tra err là lỗi DNS "không tìm thấy" (NXDOMAIN, ...)
+func isDNSNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// hasDomainSuffix kiểm tra host là domain hoặc subdomain của một trong domains
+func hasDomainSuffix(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordBot ghi nhận một request theo phân loại bot
+func (m *Metrics) RecordBot(class BotClass) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.botCounts == nil {
+		m.botCounts = make(map[BotClass]uint64)
+	}
+	m.botCounts[class]++
+}
+
+// botShare trả về số request theo class và tỷ lệ request không phải human
+func botShare(counts map[BotClass]uint64) map[string]interface{} {
+	var total, bots uint64
+	for class, n := range counts {
+		total += n
+		if class != BotClassHuman {
+			bots += n
+		}
+	}
+	share := 0.0
+	if total > 0 {
+		share = float64(bots) / float64(total)
+	}
+	return map[string]interface{}{
+		"classes": counts,
+		"share":   share,
+	}
+}
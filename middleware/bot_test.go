@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeBotResolver trả về kết quả DNS cố định, hostErr làm LookupHost thất bại
+type fakeBotResolver struct {
+	names   []string
+	addrs   []string
+	hostErr error
+}
+
+func (r *fakeBotResolver) LookupAddr(context.Context, string) ([]string, error) {
+	return r.names, nil
+}
+
+func (r *fakeBotResolver) LookupHost(context.Context, string) ([]string, error) {
+	return r.addrs, r.hostErr
+}
+
+// botServer là engine chỉ có BotMiddleware, ghi lại class của request cuối
+type botServer struct {
+	engine *gin.Engine
+	class  BotClass
+}
+
+func newBotServer(config BotConfig) *botServer {
+	gin.SetMode(gin.TestMode)
+	s := &botServer{engine: gin.New()}
+	s.engine.Use(BotMiddleware(config))
+	s.engine.GET("/", func(c *gin.Context) {
+		detection, _ := DetectedBot(c)
+		s.class = detection.Class
+		c.Status(http.StatusOK)
+	})
+	return s
+}
+
+// serve gửi một request với user agent ua từ 66.249.66.1 và trả về status code
+func (s *botServer) serve(ua string) int {
+	s.class = ""
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "66.249.66.1:1234"
+	req.Header.Set("User-Agent", ua)
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestBotForwardLookupErrorIsNotCached(t *testing.T) {
+	resolver := &fakeBotResolver{
+		names:   []string{"crawl-66-249-66-1.googlebot.com."},
+		addrs:   []string{"66.249.66.1"},
+		hostErr: errors.New("i/o timeout"),
+	}
+	s := newBotServer(BotConfig{Resolver: resolver})
+
+	s.serve("Googlebot/2.1")
+	if s.class != BotClassUnverified {
+		t.Fatalf("with DNS error: got %q, want %q", s.class, BotClassUnverified)
+	}
+	resolver.hostErr = nil
+	s.serve("Googlebot/2.1")
+	if s.class != BotClassVerified {
+		t.Fatalf("after DNS recovers: got %q, want %q (error must not be cached)", s.class, BotClassVerified)
+	}
+}
+
+func TestBotImpersonatorIsBlocked(t *testing.T) {
+	resolver := &fakeBotResolver{names: []string{"attacker.example.com."}}
+	s := newBotServer(BotConfig{Resolver: resolver})
+
+	if code := s.serve("Googlebot/2.1"); code != http.StatusForbidden {
+		t.Fatalf("got %d, want 403", code)
+	}
+}
+
+func TestBotThrottleByDefault(t *testing.T) {
+	s := newBotServer(BotConfig{Resolver: &fakeBotResolver{}})
+
+	for i := 0; i < defaultBotBurst; i++ {
+		if code := s.serve("python-requests/2.31"); code != http.StatusOK {
+			t.Fatalf("request %d: got %d, want 200 within the default burst", i, code)
+		}
+	}
+	if code := s.serve("python-requests/2.31"); code != http.StatusTooManyRequests {
+		t.Fatalf("over default burst: got %d, want 429", code)
+	}
+	if code := s.serve("Mozilla/5.0"); code != http.StatusOK {
+		t.Fatalf("human: got %d, want 200", code)
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"bytes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bufferedWriter là wrapper giữ toàn bộ status và body trong bộ nhớ
+// cho tới khi flush được gọi, dùng cho các middleware cần xử lý body
+// hoàn chỉnh trước khi gửi về client (ví dụ: ký response).
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	status  int
+	written bool
+}
+
+// newBufferedWriter tạo bufferedWriter bọc quanh writer hiện tại
+func newBufferedWriter(w gin.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, status: w.Status()}
+}
+
+// WriteHeader ghi nhận status code, có thể thay đổi cho tới khi body được ghi
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow đánh dấu header đã được "ghi" nhưng chưa gửi xuống client
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+// Write ghi dữ liệu vào buffer
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.body.Write(b)
+}
+
+// WriteString ghi chuỗi vào buffer
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+// Status trả về status code đang được giữ
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+// Size trả về số byte body đã được buffer
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+// Written cho biết handler đã ghi response hay chưa
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush bị vô hiệu hoá vì body chỉ được gửi khi middleware gọi flush
+func (w *bufferedWriter) Flush() {}
+
+// flush gửi status và body đã buffer xuống writer gốc
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CertReloader giữ cặp certificate/key TLS và nạp lại khi file thay đổi (ví dụ
+// sau khi cert-manager hoặc certbot xoay vòng certificate). Kết nối đang mở giữ
+// certificate cũ; handshake mới dùng certificate mới mà không cần restart.
+type CertReloader struct {
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+
+	mu       sync.Mutex
+	certMod  time.Time
+	keyMod   time.Time
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewCertReloader nạp certificate lần đầu (phải thành công) và kiểm tra mtime của
+// hai file sau mỗi interval (mặc định 1 phút) để nạp lại. Gọi Close để dừng.
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, done: make(chan struct{})}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := r.Reload(); err != nil {
+					logMessage("[TLS] keeping current certificate: %v", err)
+				}
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return r, nil
+}
+
+// Reload nạp lại certificate nếu một trong hai file đã thay đổi kể từ lần nạp trước.
+// Lỗi khi nạp (ví dụ mới ghi xong cert mà chưa ghi key) giữ nguyên certificate
+// hiện tại; lần kiểm tra sau sẽ thử lại.
+func (r *CertReloader) Reload() (reloaded bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return false, fmt.Errorf("tls certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("tls key: %w", err)
+	}
+	if r.cert.Load() != nil && certInfo.ModTime().Equal(r.certMod) && keyInfo.ModTime().Equal(r.keyMod) {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("tls certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return false, fmt.Errorf("tls certificate: %w", err)
+	}
+	cert.Leaf = leaf
+
+	previous := r.cert.Swap(&cert)
+	r.certMod, r.keyMod = certInfo.ModTime(), keyInfo.ModTime()
+	if previous != nil {
+		logMessage("[TLS] certificate reloaded from %s: subject %q, serial %s, expires %s",
+			r.certFile, leaf.Subject.CommonName, leaf.SerialNumber, leaf.NotAfter.Format(time.RFC3339))
+	}
+	return true, nil
+}
+
+// GetCertificate dùng cho tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Certificate trả về certificate đang dùng
+func (r *CertReloader) Certificate() *tls.Certificate {
+	return r.cert.Load()
+}
+
+// Close dừng việc theo dõi file
+func (r *CertReloader) Close() {
+	r.stopOnce.Do(func() { close(r.done) })
+}
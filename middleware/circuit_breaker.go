@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BreakerRetryAfter là interface tuỳ chọn của BreakerState, cho biết còn bao lâu
+// thì breaker chuyển sang half-open; CircuitBreakerMiddleware dùng giá trị này
+// làm Retry-After thay cho CircuitBreakerConfig.DefaultRetryAfter
+type BreakerRetryAfter interface {
+	RetryAfter() time.Duration
+}
+
+// CircuitBreakerConfig cấu hình cho CircuitBreakerMiddleware
+type CircuitBreakerConfig struct {
+	Breaker           BreakerState  // Breaker tới upstream mà route phụ thuộc, bắt buộc
+	Name              string        // Tên upstream, dùng trong lý do abort
+	DefaultRetryAfter time.Duration // Retry-After khi Breaker không implement BreakerRetryAfter, mặc định 30 giây
+	MaxRetryAfter     time.Duration // Retry-After lớn nhất, mặc định 5 phút
+}
+
+// Validate kiểm tra tính hợp lệ của CircuitBreakerConfig
+func (c CircuitBreakerConfig) Validate() error {
+	var errs configErrors
+	if c.Breaker == nil {
+		errs.addf("Breaker is required")
+	}
+	if c.DefaultRetryAfter < 0 || c.MaxRetryAfter < 0 {
+		errs.addf("DefaultRetryAfter/MaxRetryAfter must not be negative")
+	}
+	return errs.err()
+}
+
+// CircuitBreakerMiddleware trả về middleware từ chối (503) request khi breaker
+// tới upstream đang mở, thay vì để handler chờ một upstream chắc chắn lỗi.
+// Retry-After là thời gian còn lại tới khi breaker thử lại (xem BreakerRetryAfter),
+// để client không gọi lại trong lúc breaker vẫn mở.
+func CircuitBreakerMiddleware(config CircuitBreakerConfig) gin.HandlerFunc {
+	mustValidate("CircuitBreaker", config)
+	if config.DefaultRetryAfter == 0 {
+		config.DefaultRetryAfter = 30 * time.Second
+	}
+	if config.MaxRetryAfter == 0 {
+		config.MaxRetryAfter = 5 * time.Minute
+	}
+
+	return func(c *gin.Context) {
+		if !config.Breaker.IsOpen() {
+			c.Next()
+			return
+		}
+		retryAfter := config.DefaultRetryAfter
+		if r, ok := config.Breaker.(BreakerRetryAfter); ok {
+			retryAfter = r.RetryAfter()
+		}
+		abortServiceUnavailable(c, clampDuration(retryAfter, time.Second, config.MaxRetryAfter),
+			"circuit_breaker", "circuit breaker open: "+config.Name, MessageBreakerOpen)
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest là status (theo quy ước của nginx) dùng trong log và
+// metrics cho request mà client đã ngắt kết nối trước khi response được ghi,
+// hoặc kết thúc bằng 5xx sau khi client ngắt kết nối
+const StatusClientClosedRequest = 499
+
+// ClientGone cho biết client đã ngắt kết nối (context của request bị huỷ)
+func ClientGone(c *gin.Context) bool {
+	return errors.Is(c.Request.Context().Err(), context.Canceled)
+}
+
+// AbortOnClientGoneMiddleware trả về middleware dừng chain (không ghi response)
+// khi client đã ngắt kết nối, để các middleware/handler phía sau không làm
+// những việc vô ích. Nên đặt sau các middleware tốn thời gian (auth, rate limit, ...).
+func AbortOnClientGoneMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ClientGone(c) {
+			SetAbortReason(c, "client_disconnect", "client closed the connection", StatusClientClosedRequest)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxClientVersionLength là độ dài tối đa của version được ghi nhận
+	maxClientVersionLength = 64
+	// maxClientVersionsPerRoute giới hạn số version khác nhau mỗi route để tránh bùng nổ metrics
+	maxClientVersionsPerRoute = 100
+)
+
+// ClientVersionMiddleware trả về middleware đếm số request theo version của client
+// (đọc từ header, mặc định "X-Client-Version") cho từng route. Kết quả nằm trong
+// metrics dưới key "client_versions", giúp biết khi nào có thể ngừng hỗ trợ
+// các bản app cũ. Request không có header được đếm là "unknown". Request không
+// khớp route nào không được đếm, để số key chỉ giới hạn trong các route đã đăng ký.
+//
+// Panic nếu header không phải tên header hợp lệ.
+func ClientVersionMiddleware(header string) gin.HandlerFunc {
+	mustValidate("ClientVersion", validatorFunc(func() error {
+		var errs configErrors
+		errs.checkHeaderName("header", header)
+		return errs.err()
+	}))
+	if header == "" {
+		header = "X-Client-Version"
+	}
+	return func(c *gin.Context) {
+		if route := c.FullPath(); route != "" {
+			metricsOf(c).RecordClientVersion(c.Request.Method+" "+route, c.GetHeader(header))
+		}
+		c.Next()
+	}
+}
+
+// RecordClientVersion ghi nhận một request của client version tới route
+func (m *Metrics) RecordClientVersion(route, version string) {
+	switch {
+	case version == "":
+		version = "unknown"
+	case len(version) > maxClientVersionLength || !validRequestID(version):
+		version = "invalid"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.clientVersions == nil {
+		m.clientVersions = make(map[string]map[string]uint64)
+	}
+	versions, ok := m.clientVersions[route]
+	if !ok {
+		versions = make(map[string]uint64)
+		m.clientVersions[route] = versions
+	}
+	if _, seen := versions[version]; !seen && len(versions) >= maxClientVersionsPerRoute {
+		version = "other"
+	}
+	versions[version]++
+}
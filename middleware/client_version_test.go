@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientVersionIgnoresUnmatchedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core := &Core{Logger: discardLogger{}, Metrics: NewMetrics()}
+	r := gin.New()
+	core.Attach(r, nil)
+	r.Use(ClientVersionMiddleware(""))
+	r.GET("/orders/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, target := range []string{"/orders/1", "/orders/2", "/random-1", "/random-2"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-Client-Version", "2.3.0")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	req := httptest.NewRequest("PROPFIND", "/orders/1", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	versions := core.Metrics.GetMetrics()["client_versions"].(map[string]map[string]uint64)
+	if len(versions) != 1 || versions["GET /orders/:id"]["2.3.0"] != 2 {
+		t.Fatalf("got %v, want only GET /orders/:id with 2.3.0=2", versions)
+	}
+}
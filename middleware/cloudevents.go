@@ -0,0 +1,279 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CloudEvents types emitted by CloudEventsLogger, before CloudEventsConfig.TypePrefix
+const (
+	CloudEventRequestReceived  = "request.received"
+	CloudEventRequestCompleted = "request.completed"
+	CloudEventRequestPanicked  = "request.panicked"
+)
+
+// cloudEventsContentType is the media type of a CloudEvent in JSON structured mode
+const cloudEventsContentType = "application/cloudevents+json"
+
+// CloudEvent is a CloudEvents 1.0 event in JSON structured mode
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            LogEntry  `json:"data"`
+}
+
+// CloudEventsTransport delivers an encoded CloudEvent to a target
+type CloudEventsTransport interface {
+	Send(ctx context.Context, event CloudEvent, payload []byte) error
+}
+
+// KafkaProducer is the minimal producer API used by the Kafka transport. Write a
+// thin adapter for the client in use (sarama, franz-go, segmentio/kafka-go, ...)
+// so the package does not depend on a specific Kafka library.
+type KafkaProducer interface {
+	// Produce publishes one message and returns once it is acknowledged
+	Produce(ctx context.Context, topic string, key, value []byte, headers map[string]string) error
+}
+
+// httpCloudEventsTransport POSTs events to a URL
+type httpCloudEventsTransport struct {
+	url    string
+	client *http.Client
+}
+
+// NewCloudEventsHTTPTransport returns a transport that POSTs each event in
+// structured mode to url; client defaults to http.DefaultClient
+func NewCloudEventsHTTPTransport(url string, client *http.Client) CloudEventsTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpCloudEventsTransport{url: url, client: client}
+}
+
+// Send implements CloudEventsTransport, treating any non-2xx status as a failure
+func (t *httpCloudEventsTransport) Send(ctx context.Context, _ CloudEvent, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", cloudEventsContentType)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded %s", t.url, resp.Status)
+	}
+	return nil
+}
+
+// kafkaCloudEventsTransport publishes events to a Kafka topic
+type kafkaCloudEventsTransport struct {
+	producer KafkaProducer
+	topic    string
+}
+
+// NewCloudEventsKafkaTransport returns a transport that publishes each event in
+// structured mode to topic, keyed by request ID so the events of one request
+// stay ordered within a partition
+func NewCloudEventsKafkaTransport(producer KafkaProducer, topic string) CloudEventsTransport {
+	return &kafkaCloudEventsTransport{producer: producer, topic: topic}
+}
+
+// Send implements CloudEventsTransport
+func (t *kafkaCloudEventsTransport) Send(ctx context.Context, event CloudEvent, payload []byte) error {
+	headers := map[string]string{"content-type": cloudEventsContentType}
+	return t.producer.Produce(ctx, t.topic, []byte(event.Data.RequestID), payload, headers)
+}
+
+// CloudEventsConfig configures NewCloudEventsLogger
+type CloudEventsConfig struct {
+	Source     string               // CloudEvents source attribute, required, e.g. "/services/orders"
+	TypePrefix string               // Prepended to event types, e.g. "com.example." gives "com.example.request.completed"
+	Events     []string             // Event types to emit (without TypePrefix), default all
+	Transport  CloudEventsTransport // Delivery target, required
+	Timeout    time.Duration        // Max time of one delivery, default 5s
+	Queue      AsyncLoggerConfig    // Queue between the request path and the transport
+}
+
+// Validate checks the CloudEventsConfig for invalid values
+func (c CloudEventsConfig) Validate() error {
+	var errs configErrors
+	if c.Source == "" {
+		errs.addf("Source is required")
+	}
+	if c.Transport == nil {
+		errs.addf("Transport is required")
+	}
+	for _, event := range c.Events {
+		switch event {
+		case CloudEventRequestReceived, CloudEventRequestCompleted, CloudEventRequestPanicked:
+		default:
+			errs.addf("Events: unknown event type %q", event)
+		}
+	}
+	if c.Timeout < 0 {
+		errs.addf("Timeout must not be negative, got %v", c.Timeout)
+	}
+	if err := c.Queue.Validate(); err != nil {
+		errs.addf("Queue: %v", err)
+	}
+	return errs.err()
+}
+
+// CloudEventsLogger wraps a Logger and additionally emits request lifecycle
+// events as CloudEvents with the LogEntry as data: request.received for
+// LogRequest, request.completed for LogResponse and request.panicked for
+// LogError with a *PanicError. Entries reach the backend synchronously as
+// before; events go through an AsyncLogger queue so a slow target never
+// stalls requests. Call Close on shutdown to flush queued events.
+type CloudEventsLogger struct {
+	backend Logger
+	events  *AsyncLogger
+}
+
+// NewCloudEventsLogger wraps backend (which may be nil to only emit events)
+func NewCloudEventsLogger(backend Logger, config CloudEventsConfig) *CloudEventsLogger {
+	mustValidate("CloudEvents", config)
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if len(config.Events) == 0 {
+		config.Events = []string{CloudEventRequestReceived, CloudEventRequestCompleted, CloudEventRequestPanicked}
+	}
+
+	emitter := &cloudEventsEmitter{config: config, enabled: make(map[string]bool, len(config.Events))}
+	for _, event := range config.Events {
+		emitter.enabled[event] = true
+	}
+	return &CloudEventsLogger{backend: backend, events: NewAsyncLogger(emitter, config.Queue)}
+}
+
+// LogRequest implements Logger interface for CloudEventsLogger
+func (l *CloudEventsLogger) LogRequest(entry LogEntry) {
+	if l.backend != nil {
+		l.backend.LogRequest(entry)
+	}
+	l.events.LogRequest(entry)
+}
+
+// LogResponse implements Logger interface for CloudEventsLogger
+func (l *CloudEventsLogger) LogResponse(entry LogEntry) {
+	if l.backend != nil {
+		l.backend.LogResponse(entry)
+	}
+	l.events.LogResponse(entry)
+}
+
+// LogError implements Logger interface for CloudEventsLogger
+func (l *CloudEventsLogger) LogError(requestID string, err error) {
+	if l.backend != nil {
+		l.backend.LogError(requestID, err)
+	}
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		l.events.LogError(requestID, err)
+	}
+}
+
+// LogMessage implements MessageLogger interface for CloudEventsLogger
+func (l *CloudEventsLogger) LogMessage(message string) {
+	if ml, ok := l.backend.(MessageLogger); ok {
+		ml.LogMessage(message)
+		return
+	}
+	log.Println(message)
+}
+
+// LoggerStats implements LoggerStats interface for CloudEventsLogger
+func (l *CloudEventsLogger) LoggerStats() map[string]interface{} {
+	stats := map[string]interface{}{"cloudevents": l.events.LoggerStats()}
+	if s, ok := l.backend.(LoggerStats); ok {
+		stats["backend"] = s.LoggerStats()
+	}
+	return stats
+}
+
+// Close flushes queued events or gives up when ctx is done; the backend is not closed
+func (l *CloudEventsLogger) Close(ctx context.Context) error {
+	return l.events.Close(ctx)
+}
+
+// cloudEventsEmitter turns log records into CloudEvents and sends them
+type cloudEventsEmitter struct {
+	config  CloudEventsConfig
+	enabled map[string]bool
+}
+
+// LogRequest implements Logger interface for cloudEventsEmitter
+func (e *cloudEventsEmitter) LogRequest(entry LogEntry) {
+	_ = e.TryLog(LogRecord{Kind: LogKindRequest, Entry: &entry})
+}
+
+// LogResponse implements Logger interface for cloudEventsEmitter
+func (e *cloudEventsEmitter) LogResponse(entry LogEntry) {
+	_ = e.TryLog(LogRecord{Kind: LogKindResponse, Entry: &entry})
+}
+
+// LogError implements Logger interface for cloudEventsEmitter
+func (e *cloudEventsEmitter) LogError(requestID string, err error) {
+	_ = e.TryLog(LogRecord{Kind: LogKindError, RequestID: requestID, Err: err})
+}
+
+// TryLog implements FallibleLogger interface for cloudEventsEmitter
+func (e *cloudEventsEmitter) TryLog(record LogRecord) error {
+	var eventType string
+	var entry LogEntry
+	var panicErr *PanicError
+	switch {
+	case record.Kind == LogKindRequest:
+		eventType, entry = CloudEventRequestReceived, *record.Entry
+	case record.Kind == LogKindResponse:
+		eventType, entry = CloudEventRequestCompleted, *record.Entry
+	case record.Kind == LogKindError && errors.As(record.Err, &panicErr):
+		eventType, entry = CloudEventRequestPanicked, panicErr.Entry
+	default:
+		return nil
+	}
+	if !e.enabled[eventType] {
+		return nil
+	}
+
+	event := CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              uuid.NewString(),
+		Source:          e.config.Source,
+		Type:            e.config.TypePrefix + eventType,
+		Subject:         entry.Path,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            entry,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("cloudevents: encode %s: %w", event.Type, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+	defer cancel()
+	if err := e.config.Transport.Send(ctx, event, payload); err != nil {
+		return fmt.Errorf("cloudevents: send %s: %w", event.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTransport is a CloudEventsTransport keeping every event it is sent
+type recordingTransport struct {
+	mu       sync.Mutex
+	events   []CloudEvent
+	payloads [][]byte
+}
+
+func (t *recordingTransport) Send(_ context.Context, event CloudEvent, payload []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+	t.payloads = append(t.payloads, payload)
+	return nil
+}
+
+// emitLifecycle logs a request, its response, a panic and a plain error, then flushes the events
+func emitLifecycle(t *testing.T, backend Logger, config CloudEventsConfig) {
+	t.Helper()
+	l := NewCloudEventsLogger(backend, config)
+	entry := LogEntry{Method: "GET", Path: "/orders/1", RequestID: "req-1", StatusCode: 200}
+	l.LogRequest(entry)
+	l.LogResponse(entry)
+	l.LogError("req-1", &PanicError{Err: errors.New("boom"), Entry: LogEntry{Path: "/orders/1", RequestID: "req-1", StatusCode: 500}})
+	l.LogError("req-1", errors.New("not a panic"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := l.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestCloudEventsLoggerEmitsLifecycleEvents(t *testing.T) {
+	transport := &recordingTransport{}
+	backend := &errorLogger{}
+	emitLifecycle(t, backend, CloudEventsConfig{Source: "/services/orders", TypePrefix: "com.example.", Transport: transport})
+
+	if len(backend.errs) != 2 {
+		t.Fatalf("backend errors: got %d, want 2", len(backend.errs))
+	}
+	want := map[string]int{
+		"com.example.request.received":  200,
+		"com.example.request.completed": 200,
+		"com.example.request.panicked":  500,
+	}
+	if len(transport.events) != len(want) {
+		t.Fatalf("events: got %d, want %d", len(transport.events), len(want))
+	}
+	ids := make(map[string]bool)
+	for i, event := range transport.events {
+		status, ok := want[event.Type]
+		if !ok {
+			t.Fatalf("unexpected event type %q", event.Type)
+		}
+		delete(want, event.Type)
+		if event.SpecVersion != "1.0" || event.Source != "/services/orders" || event.Subject != "/orders/1" ||
+			event.DataContentType != "application/json" || event.Time.IsZero() {
+			t.Fatalf("%s attributes: got %+v", event.Type, event)
+		}
+		if event.Data.RequestID != "req-1" || event.Data.StatusCode != status {
+			t.Fatalf("%s data: got %+v, want req-1 with status %d", event.Type, event.Data, status)
+		}
+		if ids[event.ID] {
+			t.Fatalf("%s reuses event ID %q", event.Type, event.ID)
+		}
+		ids[event.ID] = true
+
+		var decoded CloudEvent
+		if err := json.Unmarshal(transport.payloads[i], &decoded); err != nil || decoded.ID != event.ID || decoded.Type != event.Type {
+			t.Fatalf("%s payload %s does not encode the event (%v)", event.Type, transport.payloads[i], err)
+		}
+	}
+}
+
+func TestCloudEventsLoggerFiltersEvents(t *testing.T) {
+	transport := &recordingTransport{}
+	emitLifecycle(t, nil, CloudEventsConfig{Source: "/services/orders", Events: []string{CloudEventRequestCompleted}, Transport: transport})
+
+	if len(transport.events) != 1 || transport.events[0].Type != CloudEventRequestCompleted {
+		t.Fatalf("events: got %+v, want only %s", transport.events, CloudEventRequestCompleted)
+	}
+}
+
+func TestCloudEventsHTTPTransport(t *testing.T) {
+	var contentType string
+	var body []byte
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	transport := NewCloudEventsHTTPTransport(server.URL, nil)
+	if err := transport.Send(context.Background(), CloudEvent{}, []byte(`{"id":"1"}`)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if contentType != cloudEventsContentType || string(body) != `{"id":"1"}` {
+		t.Fatalf("request: got %q %q, want %q with the payload", contentType, body, cloudEventsContentType)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := transport.Send(context.Background(), CloudEvent{}, []byte(`{}`)); err == nil {
+		t.Fatalf("Send on 503 must fail")
+	}
+}
+
+// recordingProducer is a KafkaProducer keeping the last produced message
+type recordingProducer struct {
+	topic   string
+	key     []byte
+	headers map[string]string
+}
+
+func (p *recordingProducer) Produce(_ context.Context, topic string, key, _ []byte, headers map[string]string) error {
+	p.topic, p.key, p.headers = topic, key, headers
+	return nil
+}
+
+func TestCloudEventsKafkaTransportKeysByRequestID(t *testing.T) {
+	producer := &recordingProducer{}
+	transport := NewCloudEventsKafkaTransport(producer, "request-events")
+	if err := transport.Send(context.Background(), CloudEvent{Data: LogEntry{RequestID: "req-1"}}, []byte(`{}`)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if producer.topic != "request-events" || string(producer.key) != "req-1" || producer.headers["content-type"] != cloudEventsContentType {
+		t.Fatalf("message: got topic %q key %q headers %v", producer.topic, producer.key, producer.headers)
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kimxuanhong/go-middleware/store"
+)
+
+// ConcurrencyLimitConfig cấu hình cho ConcurrencyLimitMiddleware
+type ConcurrencyLimitConfig struct {
+	Store         store.Store                 // Store dùng chung giữa các instance, bắt buộc
+	UserFunc      func(c *gin.Context) string // Trả về user đã xác thực; "" để bỏ qua request
+	MaxConcurrent int64                       // Số request đồng thời tối đa mỗi user
+	// TTL là thời gian sống của bộ đếm, để slot không bị giữ mãi khi instance bị crash.
+	// Tính từ request đầu tiên của một đợt (bộ đếm bị xoá khi về 0), nên lớn hơn
+	// thời gian xử lý request dài nhất; mặc định 1 phút.
+	TTL time.Duration
+}
+
+// Validate kiểm tra tính hợp lệ của ConcurrencyLimitConfig
+func (c ConcurrencyLimitConfig) Validate() error {
+	var errs configErrors
+	if c.Store == nil {
+		errs.addf("Store is required: concurrency limits must be shared across instances")
+	}
+	if c.UserFunc == nil {
+		errs.addf("UserFunc is required")
+	}
+	if c.MaxConcurrent <= 0 {
+		errs.addf("MaxConcurrent must be positive, got %d", c.MaxConcurrent)
+	}
+	if c.TTL < 0 {
+		errs.addf("TTL must not be negative, got %v", c.TTL)
+	}
+	return errs.err()
+}
+
+// ConcurrencyLimitMiddleware trả về middleware giới hạn số request đang xử lý
+// đồng thời của mỗi user (dùng store chung nên áp dụng trên toàn cluster),
+// từ chối phần vượt bằng 429 — ngăn việc chia sẻ tài khoản/credential.
+// Khi store lỗi, request được cho qua (fail open) và lỗi được log.
+func ConcurrencyLimitMiddleware(config ConcurrencyLimitConfig) gin.HandlerFunc {
+	mustValidate("ConcurrencyLimit", config)
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+
+	return func(c *gin.Context) {
+		user := config.UserFunc(c)
+		if user == "" {
+			c.Next()
+			return
+		}
+
+		key := "concurrency:" + user
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		active, err := config.Store.Incr(ctx, key, 1, config.TTL)
+		if err != nil {
+			loggerOf(c).LogError(RequestID(c), fmt.Errorf("concurrency limit store: %w", err))
+			c.Next()
+			return
+		}
+		defer releaseConcurrencySlot(c, config.Store, key, config.TTL)
+
+		if active > config.MaxConcurrent {
+			AbortWithReason(c, 429, "concurrency_limit", fmt.Sprintf("%d concurrent requests, limit %d", active, config.MaxConcurrent), gin.H{
+				"message": Message(c, MessageTooManyConcurrent),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// releaseConcurrencySlot trả slot của request. Incr chỉ đặt TTL khi tạo key, nên
+// nếu bộ đếm đã hết hạn trong lúc request còn chạy, lần trừ này tạo lại key với
+// giá trị âm và giới hạn bị nới ra; xoá key khi bộ đếm về <= 0 để nó không trôi âm.
+func releaseConcurrencySlot(c *gin.Context, s store.Store, key string, ttl time.Duration) {
+	ctx := context.WithoutCancel(c.Request.Context())
+	active, err := s.Incr(ctx, key, -1, ttl)
+	if err == nil && active <= 0 {
+		err = s.Delete(ctx, key)
+	}
+	if err != nil {
+		loggerOf(c).LogError(RequestID(c), fmt.Errorf("concurrency limit release: %w", err))
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kimxuanhong/go-middleware/store"
+)
+
+func TestConcurrencyLimitCounterDoesNotDriftAfterExpiry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewMemoryStore(store.MemoryConfig{})
+	defer s.Close()
+
+	const ttl = 50 * time.Millisecond
+	release := make(chan struct{})
+	entered := make(chan struct{}, 4)
+	r := gin.New()
+	r.Use(ConcurrencyLimitMiddleware(ConcurrencyLimitConfig{
+		Store:         s,
+		UserFunc:      func(*gin.Context) string { return "alice" },
+		MaxConcurrent: 1,
+		TTL:           ttl,
+	}))
+	r.GET("/", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	serve := func() <-chan int {
+		done := make(chan int, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			done <- w.Code
+		}()
+		return done
+	}
+
+	// Request giữ slot lâu hơn TTL: bộ đếm hết hạn khi request còn chạy
+	first := serve()
+	<-entered
+	time.Sleep(2 * ttl)
+	release <- struct{}{}
+	if code := <-first; code != http.StatusOK {
+		t.Fatalf("first request: got %d, want 200", code)
+	}
+	if v, ok, _ := s.Get(context.Background(), "concurrency:alice"); ok {
+		t.Fatalf("counter left behind after release: %s", v)
+	}
+
+	// Giới hạn vẫn phải được áp dụng sau khi bộ đếm hết hạn
+	second := serve()
+	<-entered
+	if code := <-serve(); code != http.StatusTooManyRequests {
+		t.Fatalf("concurrent request: got %d, want 429", code)
+	}
+	release <- struct{}{}
+	if code := <-second; code != http.StatusOK {
+		t.Fatalf("second request: got %d, want 200", code)
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Các key dùng để lưu dữ liệu theo request trong gin.Context.
+// Nên dùng các accessor (RequestID, StartTime, Tenant, ...) thay vì đọc trực tiếp.
+const (
+	ContextKeyRequestID         = "requestID"         // string, request ID
+	ContextKeyStartTime         = "startTime"         // time.Time, thời điểm bắt đầu xử lý
+	ContextKeyTenant            = "tenant"            // string, tenant của request
+	ContextKeyLastModified      = "lastModified"      // time.Time, mtime khai báo qua SetLastModified
+	ContextKeyRateLimitDecision = "rateLimitDecision" // string, quyết định rate limit tổng hợp
+	ContextKeyFingerprint       = "fingerprint"       // string, fingerprint của client
+	ContextKeyAudit             = "audit"             // trạng thái trước/sau đăng ký qua AuditBefore/AuditAfter
+	ContextKeyDryRun            = "dryRun"            // bool, request là dry-run
+	ContextKeyTx                = "tx"                // Tx, transaction mở bởi TransactionMiddleware
+	ContextKeyAfterSuccess      = "afterSuccess"      // hook đăng ký qua AfterSuccess
+	ContextKeyBot               = "bot"               // BotDetection, kết quả nhận diện của BotMiddleware
+	ContextKeyLogSampled        = "logSampled"        // bool, request được chọn để log (xem LogSamplingMiddleware)
+	ContextKeyLogForced         = "logForced"         // bool, log được ép qua debug header
+	ContextKeyDeferredLog       = "deferredLog"       // *LogEntry, entry request chờ quyết định log khi request kết thúc
+	ContextKeyAbort             = "abort"             // AbortInfo, middleware đã dừng chain và lý do (xem AbortWithReason)
+	ContextKeyResponseLogged    = "responseLogged"    // bool, LogResponseMiddleware đã chạy cho request
+	ContextKeyLocale            = "locale"            // string, locale của request (xem LocaleMiddleware)
+	ContextKeyCore              = "core"              // Core gắn với engine của request (xem Core.Attach)
+	ContextKeySelfTest          = "selfTest"          // bool, request giả của RunSelfTest
+	ContextKeyRequestBytes      = "requestBytes"      // int64, số byte body request LogRequestMiddleware đã đọc
+)
+
+// RequestID trả về request ID của request hiện tại, rỗng nếu chưa được gán
+func RequestID(c *gin.Context) string {
+	return c.GetString(ContextKeyRequestID)
+}
+
+// StartTime trả về thời điểm LogRequestMiddleware bắt đầu xử lý request,
+// zero nếu chưa được set
+func StartTime(c *gin.Context) time.Time {
+	return c.GetTime(ContextKeyStartTime)
+}
+
+// IsSelfTest cho biết request hiện tại là request giả của RunSelfTest
+func IsSelfTest(c *gin.Context) bool {
+	return c.GetBool(ContextKeySelfTest)
+}
+
+// Tenant trả về tenant của request hiện tại, rỗng nếu chưa được set
+func Tenant(c *gin.Context) string {
+	return c.GetString(ContextKeyTenant)
+}
+
+// SetTenant lưu tenant của request hiện tại (thường do middleware xác thực gọi)
+func SetTenant(c *gin.Context, tenant string) {
+	c.Set(ContextKeyTenant, tenant)
+}
+
+// Fingerprint trả về fingerprint của request do FingerprintMiddleware tính,
+// rỗng nếu middleware chưa chạy
+func Fingerprint(c *gin.Context) string {
+	return c.GetString(ContextKeyFingerprint)
+}
+
+// RateLimitDecision trả về quyết định rate limit tổng hợp của request,
+// ví dụ "ip=allow(4/5),global=deny(0/100)"
+func RateLimitDecision(c *gin.Context) string {
+	return c.GetString(ContextKeyRateLimitDecision)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Core là bộ logger và metrics dùng chung cho nhiều gin.Engine, ví dụ public API
+// trên :8080 và admin trên :9090 cùng ghi vào một Metrics để số liệu được tổng
+// hợp đúng, mỗi engine gắn label riêng để vẫn phân biệt được trong log và sink.
+type Core struct {
+	Logger  Logger   // nil là logger mặc định (xem SetLogger)
+	Metrics *Metrics // nil là metrics toàn cục (xem GetMetrics)
+}
+
+// coreAttachment là core cùng label của engine, lưu trong context của request
+type coreAttachment struct {
+	core   *Core
+	labels map[string]string
+}
+
+// Attach gắn core vào engine (hoặc router group) kèm label riêng của engine, ví dụ
+// {"listener": "admin"}. Label của engine được gộp với label tĩnh (SetStaticLabels)
+// trong LogEntry.Labels và RequestSample.Labels. Phải gọi trước khi engine.Use
+// các middleware khác để chúng dùng core này.
+func (core *Core) Attach(engine gin.IRoutes, labels map[string]string) {
+	attachment := &coreAttachment{core: core}
+	if len(labels) > 0 {
+		attachment.labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			attachment.labels[k] = v
+		}
+	}
+	engine.Use(func(c *gin.Context) {
+		c.Set(ContextKeyCore, attachment)
+		c.Next()
+	})
+}
+
+// attachmentOf trả về core gắn với engine của request, nil nếu không có
+func attachmentOf(c *gin.Context) *coreAttachment {
+	if c == nil {
+		return nil
+	}
+	value, ok := c.Get(ContextKeyCore)
+	if !ok {
+		return nil
+	}
+	attachment, _ := value.(*coreAttachment)
+	return attachment
+}
+
+// loggerOf trả về logger của core gắn với request, mặc định là defaultLogger
+func loggerOf(c *gin.Context) Logger {
+	if a := attachmentOf(c); a != nil && a.core.Logger != nil {
+		return a.core.Logger
+	}
+	return defaultLogger
+}
+
+// metricsOf trả về metrics của core gắn với request, mặc định là metrics toàn cục
+func metricsOf(c *gin.Context) *Metrics {
+	if a := attachmentOf(c); a != nil && a.core.Metrics != nil {
+		return a.core.Metrics
+	}
+	return metrics
+}
+
+// labelsOf trả về label tĩnh gộp với label của engine (không copy khi engine không có label)
+func labelsOf(c *gin.Context) map[string]string {
+	static := currentLabels()
+	a := attachmentOf(c)
+	if a == nil || len(a.labels) == 0 {
+		return static
+	}
+	merged := make(map[string]string, len(static)+len(a.labels))
+	for k, v := range static {
+		merged[k] = v
+	}
+	for k, v := range a.labels {
+		merged[k] = v
+	}
+	return merged
+}
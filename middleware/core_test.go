@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statsLogger là Logger bỏ qua entry và báo LoggerStats cố định
+type statsLogger struct{ discardLogger }
+
+func (statsLogger) LoggerStats() map[string]interface{} {
+	return map[string]interface{}{"backend": "core"}
+}
+
+func TestOpsEndpointsReadCoreMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core := &Core{Logger: statsLogger{}, Metrics: NewMetrics()}
+
+	app := gin.New()
+	core.Attach(app, map[string]string{"listener": "public"})
+	app.Use(LogResponseMiddleware())
+	app.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	app.GET("/unused", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	admin := gin.New()
+	core.Attach(admin, map[string]string{"listener": "admin"})
+	RegisterOpsEndpoints(admin, OpsConfig{
+		Auth:   func(c *gin.Context) { c.Next() },
+		Engine: app,
+	})
+
+	for i := 0; i < 3; i++ {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	}
+	get := func(path string, v interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got %d, want 200", path, w.Code)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+
+	var coverage []RouteCoverageEntry
+	get("/routes/coverage", &coverage)
+	hits := make(map[string]uint64, len(coverage))
+	for _, entry := range coverage {
+		hits[entry.Path] = entry.Hits
+	}
+	if hits["/orders"] != 3 || hits["/unused"] != 0 {
+		t.Fatalf("coverage: got %v, want /orders=3 and /unused=0 from the core metrics", hits)
+	}
+
+	var snapshot map[string]interface{}
+	get("/metrics", &snapshot)
+	if logger, _ := snapshot["logger"].(map[string]interface{}); logger["backend"] != "core" {
+		t.Fatalf("metrics logger: got %v, want the stats of the core logger", snapshot["logger"])
+	}
+	if codes, _ := snapshot["status_code_counts"].(map[string]interface{}); codes["200"] != float64(3) {
+		t.Fatalf("metrics status codes: got %v, want 200=3 from the core metrics", snapshot["status_code_counts"])
+	}
+}
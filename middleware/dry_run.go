@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DryRunMiddleware trả về middleware nhận diện header X-Dry-Run ("1", "true", "yes").
+// Khi bật, context được đánh dấu (đọc qua IsDryRun) để handler bỏ qua side effect,
+// TransactionMiddleware luôn rollback, response có header X-Dry-Run: true và
+// log entry được đánh dấu DryRun.
+func DryRunMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if parseDryRun(c.GetHeader("X-Dry-Run")) {
+			c.Set(ContextKeyDryRun, true)
+			c.Header("X-Dry-Run", "true")
+		}
+		c.Next()
+	}
+}
+
+// IsDryRun cho biết request hiện tại có phải dry-run hay không
+func IsDryRun(c *gin.Context) bool {
+	return c.GetBool(ContextKeyDryRun)
+}
+
+// parseDryRun đọc giá trị header X-Dry-Run
+func parseDryRun(value string) bool {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "yes" {
+		return true
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err == nil && enabled
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kimxuanhong/go-middleware/bodyproc"
+)
+
+// encryptedFieldPrefix đánh dấu giá trị đã được mã hoá trong body log
+const encryptedFieldPrefix = "enc:v1:"
+
+// BodyHook biến đổi body (request/response) trước khi được ghi vào log
+type BodyHook func(body string) string
+
+// NewFieldEncryptionHook tạo BodyHook mã hoá (AES-GCM) giá trị của các field JSON
+// được chỉ định, ở mọi cấp lồng nhau. Giá trị sau mã hoá có dạng
+// "enc:v1:<base64(nonce|ciphertext)>" và có thể giải mã lại bằng DecryptLogField.
+// Body không phải JSON được giữ nguyên.
+//
+// key phải dài 16, 24 hoặc 32 byte (AES-128/192/256).
+func NewFieldEncryptionHook(key []byte, fields ...string) (BodyHook, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("field encryption: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("field encryption: %w", err)
+	}
+
+	return func(body string) string {
+		return bodyproc.TransformFields(body, fields, func(raw []byte) interface{} {
+			return sealField(aead, raw)
+		})
+	}, nil
+}
+
+// NewFieldRedactionHook tạo BodyHook thay giá trị của các field JSON được chỉ định
+// (mọi cấp lồng nhau) bằng "[REDACTED]", khi không cần giải mã lại như
+// NewFieldEncryptionHook. Body không phải JSON được giữ nguyên.
+func NewFieldRedactionHook(fields ...string) BodyHook {
+	return func(body string) string {
+		return bodyproc.Redact(body, fields...)
+	}
+}
+
+// sealField mã hoá một giá trị và trả về chuỗi có prefix
+func sealField(aead cipher.AEAD, plain []byte) string {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "[ENCRYPTION FAILED]"
+	}
+	sealed := aead.Seal(nonce, nonce, plain, nil)
+	return encryptedFieldPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+// DecryptLogField giải mã một giá trị được tạo bởi NewFieldEncryptionHook
+// và trả về giá trị JSON gốc của field.
+func DecryptLogField(key []byte, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedFieldPrefix) {
+		return "", errors.New("field decryption: value is not encrypted")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedFieldPrefix))
+	if err != nil {
+		return "", fmt.Errorf("field decryption: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", fmt.Errorf("field decryption: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("field decryption: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("field decryption: ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("field decryption: %w", err)
+	}
+	return string(plain), nil
+}
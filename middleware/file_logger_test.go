@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFileLog logs one request and one error with config and returns the file lines
+func writeFileLog(t *testing.T, config FileLoggerConfig) []string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "access.log")
+	l, err := NewFileLogger(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.LogRequest(LogEntry{Method: "POST", Path: "/payments", RequestID: "req-1", Request: `{"card":"4111"}`})
+	l.LogError("req-1", errors.New("card declined"))
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestFileLoggerSignsAndEncryptsLines(t *testing.T) {
+	config := FileLoggerConfig{HMACKey: []byte("hmac-key"), EncryptionKey: bytes.Repeat([]byte("e"), 32)}
+	lines := writeFileLog(t, config)
+	if len(lines) != 2 {
+		t.Fatalf("lines: got %d, want 2", len(lines))
+	}
+
+	for i, want := range []string{"/payments", "card declined"} {
+		if strings.Contains(lines[i], want) || strings.Contains(lines[i], "req-1") {
+			t.Fatalf("line %d %q is not encrypted", i, lines[i])
+		}
+		record, err := ReadFileLogLine(lines[i], config)
+		if err != nil {
+			t.Fatalf("ReadFileLogLine(line %d): %v", i, err)
+		}
+		var decoded fileLogRecord
+		if err := json.Unmarshal(record, &decoded); err != nil {
+			t.Fatalf("line %d record %q is not JSON: %v", i, record, err)
+		}
+		if decoded.ID != "req-1" || !strings.Contains(string(record), want) {
+			t.Fatalf("line %d record: got %s, want request req-1 with %q", i, record, want)
+		}
+	}
+}
+
+func TestFileLoggerDetectsTampering(t *testing.T) {
+	config := FileLoggerConfig{HMACKey: []byte("hmac-key")}
+	line := writeFileLog(t, config)[0]
+	if _, err := ReadFileLogLine(line, config); err != nil {
+		t.Fatalf("ReadFileLogLine(untouched): %v", err)
+	}
+
+	tampered := strings.Replace(line, "/payments", "/refunds", 1)
+	stripped := line[:strings.LastIndex(line, fileLogSignatureSep)]
+	for name, l := range map[string]string{"tampered": tampered, "unsigned": stripped} {
+		if _, err := ReadFileLogLine(l, config); err == nil {
+			t.Fatalf("ReadFileLogLine(%s line) must fail", name)
+		}
+	}
+	if _, err := ReadFileLogLine(line, FileLoggerConfig{HMACKey: []byte("other-key")}); err == nil {
+		t.Fatalf("ReadFileLogLine with another HMAC key must fail")
+	}
+}
+
+func TestNewFileLoggerRejectsInvalidEncryptionKey(t *testing.T) {
+	_, err := NewFileLogger(filepath.Join(t.TempDir(), "access.log"), FileLoggerConfig{EncryptionKey: []byte("short")})
+	if err == nil {
+		t.Fatalf("NewFileLogger with a 5-byte key must fail")
+	}
+}
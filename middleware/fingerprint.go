@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FingerprintConfig cấu hình cho FingerprintMiddleware
+type FingerprintConfig struct {
+	// Tracker đếm số request mỗi fingerprint trong cửa sổ trượt; nil là không đếm
+	Tracker *FingerprintTracker
+	// Threshold là số request trong cửa sổ mà vượt quá thì fingerprint bị coi là bất thường
+	Threshold int
+	// OnAnomaly được gọi mỗi khi một request có fingerprint bất thường
+	OnAnomaly func(c *gin.Context, fingerprint string, count int)
+	// Blocklist, nếu khác nil, nhận IP của fingerprint bất thường
+	Blocklist *IPBlocklist
+	BlockTTL  time.Duration
+}
+
+// Validate kiểm tra tính hợp lệ của FingerprintConfig
+func (c FingerprintConfig) Validate() error {
+	var errs configErrors
+	if c.Threshold < 0 {
+		errs.addf("Threshold must not be negative, got %d", c.Threshold)
+	}
+	if c.Threshold > 0 && c.Tracker == nil {
+		errs.addf("Tracker is required when Threshold is set")
+	}
+	if c.Tracker != nil && c.Threshold == 0 && (c.OnAnomaly != nil || c.Blocklist != nil) {
+		errs.addf("Threshold is required when OnAnomaly or Blocklist is set")
+	}
+	if c.BlockTTL < 0 {
+		errs.addf("BlockTTL must not be negative, got %v", c.BlockTTL)
+	}
+	return errs.err()
+}
+
+// FingerprintMiddleware trả về middleware tính fingerprint nhẹ cho mỗi request
+// (IP + User-Agent + hash tập header), lưu vào context (đọc qua Fingerprint)
+// và log, đồng thời đếm theo cửa sổ trượt để các tính năng chặn có thể xử lý
+// theo fingerprint thay vì chỉ theo IP.
+func FingerprintMiddleware(config FingerprintConfig) gin.HandlerFunc {
+	mustValidate("Fingerprint", config)
+	return func(c *gin.Context) {
+		fingerprint := computeFingerprint(c.ClientIP(), c.Request)
+		c.Set(ContextKeyFingerprint, fingerprint)
+
+		if config.Tracker != nil && config.Threshold > 0 {
+			if count := config.Tracker.Observe(fingerprint); count > config.Threshold {
+				if config.OnAnomaly != nil {
+					config.OnAnomaly(c, fingerprint, count)
+				}
+				if config.Blocklist != nil {
+					config.Blocklist.Block(c.ClientIP(), config.BlockTTL)
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// computeFingerprint băm IP, User-Agent và tập tên header của request.
+// net/http không giữ thứ tự header trên wire nên dùng tập tên header đã sắp xếp,
+// vẫn đủ phân biệt các client/tool khác nhau.
+func computeFingerprint(ip string, r *http.Request) string {
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	h.Write([]byte(ip))
+	h.Write([]byte{0})
+	h.Write([]byte(r.UserAgent()))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.Join(names, ",")))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// FingerprintTracker đếm số lần xuất hiện của mỗi fingerprint trong một cửa sổ
+// trượt (xấp xỉ bằng hai cửa sổ cố định liên tiếp có trọng số)
+type FingerprintTracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	start   time.Time
+	current map[string]int
+	prev    map[string]int
+}
+
+// NewFingerprintTracker tạo FingerprintTracker với độ dài cửa sổ window
+func NewFingerprintTracker(window time.Duration) *FingerprintTracker {
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &FingerprintTracker{
+		window:  window,
+		start:   time.Now(),
+		current: make(map[string]int),
+		prev:    make(map[string]int),
+	}
+}
+
+// Observe ghi nhận một request của fingerprint và trả về số request ước lượng
+// trong cửa sổ trượt hiện tại
+func (t *FingerprintTracker) Observe(fingerprint string) int {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate(now)
+	t.current[fingerprint]++
+
+	elapsed := float64(now.Sub(t.start)) / float64(t.window)
+	return t.current[fingerprint] + int(float64(t.prev[fingerprint])*(1-elapsed))
+}
+
+// Count trả về số request ước lượng của fingerprint trong cửa sổ trượt
+func (t *FingerprintTracker) Count(fingerprint string) int {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotate(now)
+	elapsed := float64(now.Sub(t.start)) / float64(t.window)
+	return t.current[fingerprint] + int(float64(t.prev[fingerprint])*(1-elapsed))
+}
+
+// rotate chuyển sang cửa sổ mới khi cửa sổ hiện tại kết thúc, caller phải giữ lock
+func (t *FingerprintTracker) rotate(now time.Time) {
+	elapsed := now.Sub(t.start)
+	if elapsed < t.window {
+		return
+	}
+	if elapsed < 2*t.window {
+		t.prev = t.current
+	} else {
+		t.prev = make(map[string]int)
+	}
+	t.current = make(map[string]int)
+	t.start = t.start.Add(elapsed.Truncate(t.window))
+}
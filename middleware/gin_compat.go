@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinLoggerCompat thay thế trực tiếp cho gin.Logger(): giữ nguyên định dạng log
+// của gin (ghi ra gin.DefaultWriter) để parser hiện tại không bị ảnh hưởng, đồng
+// thời gán request ID và ghi metrics như LogResponseMiddleware. Dùng khi chuyển
+// dần từ gin.Logger() sang LogRequestMiddleware/LogResponseMiddleware.
+func GinLoggerCompat() gin.HandlerFunc {
+	return GinLoggerCompatWithConfig(gin.LoggerConfig{})
+}
+
+// GinLoggerCompatWithConfig giống GinLoggerCompat với cấu hình như
+// gin.LoggerWithConfig (Formatter, Output, SkipPaths). Nếu chain có
+// LogResponseMiddleware phía sau, metrics do middleware đó ghi để không đếm trùng.
+func GinLoggerCompatWithConfig(config gin.LoggerConfig) gin.HandlerFunc {
+	logger := gin.LoggerWithConfig(config)
+
+	return func(c *gin.Context) {
+		start := StartTime(c)
+		if start.IsZero() {
+			start = time.Now()
+			c.Set(ContextKeyStartTime, start)
+		}
+		ensureRequestID(c)
+
+		// Logger của gin tự gọi c.Next() và ghi log sau khi chain kết thúc
+		logger(c)
+
+		if !c.GetBool(ContextKeyResponseLogged) {
+			recordRequestMetrics(c, time.Since(start), c.Writer.Status(), c.Writer.Written())
+		}
+	}
+}
+
+// GinRecoveryCompat thay thế trực tiếp cho gin.Recovery(): giữ nguyên output
+// (stack trace ra gin.DefaultErrorWriter) và hành vi (500 không body, không ghi
+// response khi client đã ngắt kết nối) của gin, đồng thời log lỗi qua Logger của
+// package với request ID của request.
+func GinRecoveryCompat() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(gin.DefaultErrorWriter, func(c *gin.Context, err any) {
+		requestID := ensureRequestID(c)
+		loggerOf(c).LogError(requestID, newPanicError(c, requestID, fmt.Errorf("Recovered from panic: %v", err)))
+		flushDeferredLog(c, 500)
+		c.AbortWithStatus(500)
+	})
+}
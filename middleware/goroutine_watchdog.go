@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// inFlightRequests đếm số request đang được xử lý (duy trì bởi LogRequestMiddleware)
+var inFlightRequests atomic.Int64
+
+// GoroutineWatchdogConfig cấu hình cho StartGoroutineWatchdog
+type GoroutineWatchdogConfig struct {
+	Interval  time.Duration // Chu kỳ lấy mẫu, mặc định 30 giây
+	Window    int           // Số mẫu liên tiếp được xét, mặc định 10
+	MinGrowth int           // Mức tăng tối thiểu của số goroutine dư trong Window để coi là rò rỉ, mặc định 100
+	// OnLeak được gọi (ngoài việc ghi log cảnh báo) khi phát hiện dấu hiệu rò rỉ, ví dụ gửi alert
+	OnLeak func(report GoroutineLeakReport)
+}
+
+// Validate kiểm tra tính hợp lệ của GoroutineWatchdogConfig
+func (c GoroutineWatchdogConfig) Validate() error {
+	var errs configErrors
+	if c.Interval < 0 {
+		errs.addf("Interval must not be negative, got %v", c.Interval)
+	}
+	if c.Window < 0 || c.Window == 1 {
+		errs.addf("Window must be at least 2, got %d", c.Window)
+	}
+	if c.MinGrowth < 0 {
+		errs.addf("MinGrowth must not be negative, got %d", c.MinGrowth)
+	}
+	return errs.err()
+}
+
+// GoroutineLeakReport mô tả một lần phát hiện dấu hiệu rò rỉ goroutine
+type GoroutineLeakReport struct {
+	Time       time.Time `json:"time"`
+	Goroutines int       `json:"goroutines"`
+	InFlight   int64     `json:"in_flight"`
+	Growth     int       `json:"growth"` // Mức tăng số goroutine dư (goroutines - in-flight) trong Window
+	Window     int       `json:"window"`
+}
+
+var (
+	goroutineSnapshotMu sync.RWMutex
+	goroutineSnapshot   []byte
+	goroutineLeak       *GoroutineLeakReport
+)
+
+// StartGoroutineWatchdog khởi động monitor theo dõi số goroutine so với số request
+// đang xử lý. Nếu số goroutine dư (goroutines - in-flight) tăng liên tục trong
+// Window mẫu và tăng ít nhất MinGrowth, watchdog ghi log cảnh báo, gọi OnLeak và
+// lưu snapshot stack của mọi goroutine (xem qua GoroutineSnapshot hoặc endpoint
+// /debug/goroutines). Cần LogRequestMiddleware để biết số request đang xử lý.
+// Trả về hàm stop.
+func StartGoroutineWatchdog(config GoroutineWatchdogConfig) (stop func()) {
+	mustValidate("GoroutineWatchdog", config)
+	if config.Interval == 0 {
+		config.Interval = 30 * time.Second
+	}
+	if config.Window == 0 {
+		config.Window = 10
+	}
+	if config.MinGrowth == 0 {
+		config.MinGrowth = 100
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		var excess []int
+		for {
+			select {
+			case <-ticker.C:
+				goroutines, inFlight := runtime.NumGoroutine(), inFlightRequests.Load()
+				excess = append(excess, goroutines-int(inFlight))
+				if len(excess) > config.Window {
+					excess = excess[1:]
+				}
+				if growth, leaking := leakPattern(excess, config.Window, config.MinGrowth); leaking {
+					reportGoroutineLeak(config, GoroutineLeakReport{
+						Time:       time.Now(),
+						Goroutines: goroutines,
+						InFlight:   inFlight,
+						Growth:     growth,
+						Window:     config.Window,
+					})
+					// Bắt đầu cửa sổ mới để không cảnh báo lặp lại ở mỗi mẫu
+					excess = excess[len(excess)-1:]
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// leakPattern kiểm tra cửa sổ mẫu đầy đủ, không giảm và tăng ít nhất minGrowth
+func leakPattern(excess []int, window, minGrowth int) (int, bool) {
+	if len(excess) < window {
+		return 0, false
+	}
+	for i := 1; i < len(excess); i++ {
+		if excess[i] < excess[i-1] {
+			return 0, false
+		}
+	}
+	growth := excess[len(excess)-1] - excess[0]
+	return growth, growth >= minGrowth
+}
+
+// reportGoroutineLeak lưu snapshot stack, ghi log cảnh báo và gọi OnLeak
+func reportGoroutineLeak(config GoroutineWatchdogConfig, report GoroutineLeakReport) {
+	snapshot := captureGoroutineStacks()
+	goroutineSnapshotMu.Lock()
+	goroutineSnapshot = snapshot
+	goroutineLeak = &report
+	goroutineSnapshotMu.Unlock()
+
+	logMessage("[WATCHDOG] possible goroutine leak: %d goroutines for %d in-flight requests, +%d over the last %d samples; stacks available via GoroutineSnapshot",
+		report.Goroutines, report.InFlight, report.Growth, report.Window)
+	if config.OnLeak != nil {
+		config.OnLeak(report)
+	}
+}
+
+// captureGoroutineStacks trả về stack của mọi goroutine, gộp theo stack giống nhau
+func captureGoroutineStacks() []byte {
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	return buf.Bytes()
+}
+
+// GoroutineSnapshot trả về snapshot stack lưu ở lần phát hiện rò rỉ gần nhất
+// và báo cáo tương ứng; nil nếu chưa phát hiện lần nào
+func GoroutineSnapshot() ([]byte, *GoroutineLeakReport) {
+	goroutineSnapshotMu.RLock()
+	defer goroutineSnapshotMu.RUnlock()
+	return goroutineSnapshot, goroutineLeak
+}
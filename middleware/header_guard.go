@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderGuardConfig cấu hình cho HeaderGuardMiddleware
+type HeaderGuardConfig struct {
+	// Limits là giới hạn số lượng/kích thước header, vượt giới hạn trả về 431
+	Limits HeaderLimitConfig
+}
+
+// Validate kiểm tra tính hợp lệ của HeaderGuardConfig
+func (c HeaderGuardConfig) Validate() error {
+	var errs configErrors
+	if err := c.Limits.Validate(); err != nil {
+		errs.addf("Limits: %v", err)
+	}
+	return errs.err()
+}
+
+// HeaderGuardMiddleware trả về middleware từ chối request có dấu hiệu request
+// smuggling hoặc header bất thường: Content-Length và Transfer-Encoding cùng lúc,
+// nhiều Content-Length, Transfer-Encoding lạ, ký tự NUL/CR/LF trong header (400),
+// quá nhiều header hoặc header quá lớn (431, xem HeaderLimitConfig). Mỗi lần từ
+// chối được log như một security event. net/http đã chặn phần lớn các trường hợp
+// này; middleware là lớp phòng thủ bổ sung khi đứng sau proxy dễ dãi hoặc server
+// khác net/http.
+func HeaderGuardMiddleware(config HeaderGuardConfig) gin.HandlerFunc {
+	mustValidate("HeaderGuard", config)
+	limits := config.Limits.withDefaults()
+
+	return func(c *gin.Context) {
+		tooLarge, reason := false, headerAnomaly(c.Request)
+		if reason == "" {
+			reason = checkHeaderLimits(c, limits)
+			tooLarge = reason != ""
+		}
+		if reason == "" {
+			c.Next()
+			return
+		}
+
+		loggerOf(c).LogError(ensureRequestID(c), fmt.Errorf("security event: %s on %s %s from %s, UserAgent: %s",
+			reason, c.Request.Method, c.Request.URL.Path, c.ClientIP(), c.Request.UserAgent()))
+		// Không tin được ranh giới request trên kết nối này nữa
+		c.Header("Connection", "close")
+		if tooLarge {
+			abortHeadersTooLarge(c, "header_guard", reason)
+			return
+		}
+		AbortWithReason(c, http.StatusBadRequest, "header_guard", reason, gin.H{
+			"message": Message(c, MessageBadRequest),
+		})
+	}
+}
+
+// headerAnomaly trả về mô tả bất thường về framing hoặc ký tự của header, rỗng nếu không có
+func headerAnomaly(r *http.Request) string {
+	contentLengths := r.Header.Values("Content-Length")
+	transferEncodings := append(append([]string(nil), r.TransferEncoding...), r.Header.Values("Transfer-Encoding")...)
+
+	if len(contentLengths) > 0 && len(transferEncodings) > 0 {
+		return "both Content-Length and Transfer-Encoding"
+	}
+	if len(contentLengths) > 1 || (len(contentLengths) == 1 && strings.Contains(contentLengths[0], ",")) {
+		return "multiple Content-Length values"
+	}
+	if len(contentLengths) == 1 && !isDigits(strings.TrimSpace(contentLengths[0])) {
+		return "invalid Content-Length"
+	}
+	for _, te := range transferEncodings {
+		if !strings.EqualFold(strings.TrimSpace(te), "chunked") {
+			return fmt.Sprintf("unsupported Transfer-Encoding %q", te)
+		}
+	}
+
+	for name, values := range r.Header {
+		if hasControlChar(name) {
+			return fmt.Sprintf("control character in header name %q", name)
+		}
+		for _, value := range values {
+			if hasControlChar(value) {
+				return fmt.Sprintf("control character in header %s", name)
+			}
+		}
+	}
+	return ""
+}
+
+// hasControlChar kiểm tra chuỗi có ký tự điều khiển (NUL, CR, LF, ...) ngoài tab
+func hasControlChar(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < 0x20 && s[i] != '\t') || s[i] == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// isDigits kiểm tra chuỗi khác rỗng chỉ gồm chữ số
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
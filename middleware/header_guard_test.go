@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHeaderGuardMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := &errorLogger{}
+	core := &Core{Logger: logger, Metrics: NewMetrics()}
+	r := gin.New()
+	core.Attach(r, nil)
+	r.Use(HeaderGuardMiddleware(HeaderGuardConfig{Limits: HeaderLimitConfig{MaxHeaders: 5}}))
+	r.POST("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	cases := []struct {
+		name       string
+		header     http.Header
+		wantStatus int
+		wantReason string
+	}{
+		{"request bình thường", http.Header{"Content-Length": {"0"}}, http.StatusOK, ""},
+		{"Content-Length và Transfer-Encoding", http.Header{"Content-Length": {"5"}, "Transfer-Encoding": {"chunked"}}, http.StatusBadRequest, "both Content-Length and Transfer-Encoding"},
+		{"nhiều Content-Length", http.Header{"Content-Length": {"5", "6"}}, http.StatusBadRequest, "multiple Content-Length values"},
+		{"Content-Length gộp", http.Header{"Content-Length": {"5, 6"}}, http.StatusBadRequest, "multiple Content-Length values"},
+		{"Content-Length không phải số", http.Header{"Content-Length": {"-1"}}, http.StatusBadRequest, "invalid Content-Length"},
+		{"Transfer-Encoding lạ", http.Header{"Transfer-Encoding": {"gzip"}}, http.StatusBadRequest, "unsupported Transfer-Encoding"},
+		{"CRLF trong giá trị", http.Header{"X-Note": {"a\r\nX-Admin: 1"}}, http.StatusBadRequest, "control character in header X-Note"},
+		{"NUL trong tên", http.Header{"X-Bad\x00": {"1"}}, http.StatusBadRequest, "control character in header name"},
+		{"quá nhiều header", http.Header{"X-A": {"1", "2", "3"}, "X-B": {"1", "2", "3"}}, http.StatusRequestHeaderFieldsTooLarge, "6 headers, limit 5"},
+	}
+	for _, tc := range cases {
+		logger.errs = nil
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		req.Header = tc.header
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.wantStatus {
+			t.Fatalf("%s: status got %d, want %d", tc.name, w.Code, tc.wantStatus)
+		}
+		if tc.wantReason == "" {
+			if len(logger.errs) != 0 {
+				t.Fatalf("%s: unexpected security event %v", tc.name, logger.errs)
+			}
+			continue
+		}
+		if len(logger.errs) != 1 || !strings.Contains(logger.errs[0].Error(), tc.wantReason) {
+			t.Fatalf("%s: security event got %v, want one containing %q", tc.name, logger.errs, tc.wantReason)
+		}
+		if got := w.Header().Get("Connection"); got != "close" {
+			t.Fatalf("%s: Connection got %q, want close", tc.name, got)
+		}
+	}
+	if core.Metrics.HeaderCountRejections != 1 {
+		t.Fatalf("header_count_rejections: got %d, want 1", core.Metrics.HeaderCountRejections)
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultHoneypotPaths là danh sách route mồi thường bị các scanner dò tìm
+var DefaultHoneypotPaths = []string{
+	"/wp-admin",
+	"/wp-login.php",
+	"/.env",
+	"/.git/config",
+	"/phpmyadmin",
+	"/admin.php",
+	"/xmlrpc.php",
+}
+
+// HoneypotConfig cấu hình cho RegisterHoneypots
+type HoneypotConfig struct {
+	Paths      []string      // Route mồi, mặc định DefaultHoneypotPaths
+	Blocklist  *IPBlocklist  // Nếu khác nil, IP truy cập route mồi sẽ bị chặn
+	BlockTTL   time.Duration // Thời gian chặn, <= 0 nghĩa là vĩnh viễn
+	StatusCode int           // Status trả về cho route mồi, mặc định 404
+}
+
+// Validate kiểm tra tính hợp lệ của HoneypotConfig
+func (c HoneypotConfig) Validate() error {
+	var errs configErrors
+	errs.checkExactPaths("Paths", c.Paths)
+	if c.StatusCode != 0 && (c.StatusCode < 100 || c.StatusCode > 599) {
+		errs.addf("StatusCode %d is not a valid HTTP status", c.StatusCode)
+	}
+	if c.BlockTTL < 0 {
+		errs.addf("BlockTTL must not be negative, got %v", c.BlockTTL)
+	}
+	return errs.err()
+}
+
+// RegisterHoneypots đăng ký các route mồi (ví dụ /wp-admin, /.env) lên router.
+// Mỗi lần truy cập được log kèm đầy đủ thông tin nhận dạng client (fingerprint, User-Agent,
+// header với giá trị nhạy cảm được ẩn), được đếm
+// trong metrics (honeypot_hits) và có thể tự động đưa IP vào blocklist.
+func RegisterHoneypots(r gin.IRoutes, config HoneypotConfig) {
+	mustValidate("Honeypot", config)
+	paths := config.Paths
+	if len(paths) == 0 {
+		paths = DefaultHoneypotPaths
+	}
+	status := config.StatusCode
+	if status == 0 {
+		status = 404
+	}
+
+	handler := func(c *gin.Context) {
+		requestID := ensureRequestID(c)
+		fingerprint := Fingerprint(c)
+		if fingerprint == "" {
+			fingerprint = computeFingerprint(c.ClientIP(), c.Request)
+		}
+
+		atomic.AddUint64(&metricsOf(c).HoneypotHits, 1)
+		loggerOf(c).LogError(requestID, fmt.Errorf("honeypot hit: %s %s from %s, Fingerprint: %s, UserAgent: %s, Headers: %s",
+			c.Request.Method, c.Request.URL.RequestURI(), c.ClientIP(), fingerprint, c.Request.UserAgent(),
+			formatLogHeaders(c.Request.Header)))
+
+		if config.Blocklist != nil {
+			config.Blocklist.Block(c.ClientIP(), config.BlockTTL)
+		}
+		AbortWithReason(c, status, "honeypot", "honeypot path", nil)
+	}
+
+	for _, path := range paths {
+		r.Any(path, handler)
+	}
+}
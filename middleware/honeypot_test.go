@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorLogger ghi lại các lỗi được log qua LogError
+type errorLogger struct {
+	discardLogger
+	errs []error
+}
+
+func (l *errorLogger) LogError(_ string, err error) { l.errs = append(l.errs, err) }
+
+func TestHoneypotLogsClientIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := &errorLogger{}
+	core := &Core{Logger: logger, Metrics: NewMetrics()}
+	r := gin.New()
+	core.Attach(r, nil)
+	RegisterHoneypots(r, HoneypotConfig{})
+
+	req := httptest.NewRequest(http.MethodGet, "/.env", nil)
+	req.Header.Set("User-Agent", "scanner/1.0")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status: got %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if len(logger.errs) != 1 {
+		t.Fatalf("logged errors: got %d, want 1", len(logger.errs))
+	}
+	msg := logger.errs[0].Error()
+	fingerprint := computeFingerprint(req.RemoteAddr[:strings.LastIndex(req.RemoteAddr, ":")], req)
+	for _, want := range []string{"Fingerprint: " + fingerprint, "UserAgent: scanner/1.0", "Authorization"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("log %q does not contain %q", msg, want)
+		}
+	}
+	if strings.Contains(msg, "secret-token") {
+		t.Fatalf("log %q leaks the Authorization value", msg)
+	}
+	if core.Metrics.HoneypotHits != 1 {
+		t.Fatalf("honeypot_hits: got %d, want 1", core.Metrics.HoneypotHits)
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// blocklistSweepInterval là khoảng cách tối thiểu giữa hai lần dọn entry hết hạn
+const blocklistSweepInterval = time.Minute
+
+// IPBlocklist lưu danh sách IP bị chặn, mỗi IP có thể có thời hạn riêng
+type IPBlocklist struct {
+	mu        sync.RWMutex
+	entries   map[string]time.Time // IP -> thời điểm hết hạn (zero = vĩnh viễn)
+	lastSweep time.Time
+}
+
+// NewIPBlocklist tạo một IPBlocklist rỗng
+func NewIPBlocklist() *IPBlocklist {
+	return &IPBlocklist{entries: make(map[string]time.Time)}
+}
+
+// Block chặn một IP trong khoảng thời gian ttl (ttl <= 0 nghĩa là vĩnh viễn).
+// Các entry đã hết hạn được dọn định kỳ tại đây, để IP chỉ bị chặn một lần
+// (ví dụ scanner đổi IP liên tục) không nằm lại trong map mãi.
+func (b *IPBlocklist) Block(ip string, ttl time.Duration) {
+	now := time.Now()
+	var expiry time.Time
+	if ttl > 0 {
+		expiry = now.Add(ttl)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Sub(b.lastSweep) >= blocklistSweepInterval {
+		b.sweep(now)
+	}
+	b.entries[ip] = expiry
+}
+
+// Unblock bỏ chặn một IP
+func (b *IPBlocklist) Unblock(ip string) {
+	b.mu.Lock()
+	delete(b.entries, ip)
+	b.mu.Unlock()
+}
+
+// IsBlocked kiểm tra IP có đang bị chặn hay không, tự xoá các entry đã hết hạn
+func (b *IPBlocklist) IsBlocked(ip string) bool {
+	b.mu.RLock()
+	expiry, ok := b.entries[ip]
+	b.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	if !expiry.IsZero() && time.Now().After(expiry) {
+		b.Unblock(ip)
+		return false
+	}
+	return true
+}
+
+// sweep xoá các entry đã hết hạn, caller phải giữ lock ghi
+func (b *IPBlocklist) sweep(now time.Time) {
+	for ip, expiry := range b.entries {
+		if !expiry.IsZero() && now.After(expiry) {
+			delete(b.entries, ip)
+		}
+	}
+	b.lastSweep = now
+}
+
+// IPBlocklistMiddleware trả về middleware từ chối (403) các request
+// đến từ IP nằm trong blocklist
+func IPBlocklistMiddleware(blocklist *IPBlocklist) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if blocklist != nil && blocklist.IsBlocked(c.ClientIP()) {
+			AbortWithReason(c, 403, "ip_blocklist", "blocked IP", gin.H{
+				"message": Message(c, MessageForbidden),
+			})
+			return
+		}
+		c.Next()
+	}
+}
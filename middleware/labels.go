@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// staticLabels là bộ label tĩnh được gắn vào mọi log entry và metrics
+var staticLabels atomic.Pointer[map[string]string]
+
+// SetStaticLabels đặt bộ label tĩnh (ví dụ màu deployment, build SHA, tên pod)
+// được tự động gắn vào mọi log entry (LogEntry.Labels) và metrics (key "labels"),
+// giúp so sánh giữa các replica trong lúc rollout blue/green.
+func SetStaticLabels(labels map[string]string) {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	staticLabels.Store(&copied)
+}
+
+// AddStaticLabels thêm (hoặc ghi đè) label vào bộ label tĩnh hiện tại
+func AddStaticLabels(labels map[string]string) {
+	merged := StaticLabels()
+	for k, v := range labels {
+		merged[k] = v
+	}
+	staticLabels.Store(&merged)
+}
+
+// StaticLabels trả về bản sao bộ label tĩnh hiện tại
+func StaticLabels() map[string]string {
+	current := staticLabels.Load()
+	if current == nil {
+		return map[string]string{}
+	}
+	copied := make(map[string]string, len(*current))
+	for k, v := range *current {
+		copied[k] = v
+	}
+	return copied
+}
+
+// StaticLabelsFromEnv đọc label từ biến môi trường theo mapping label -> tên biến,
+// ví dụ {"color": "DEPLOY_COLOR", "pod": "POD_NAME"}. Biến rỗng bị bỏ qua.
+func StaticLabelsFromEnv(mapping map[string]string) map[string]string {
+	labels := make(map[string]string, len(mapping))
+	for label, env := range mapping {
+		if v := os.Getenv(env); v != "" {
+			labels[label] = v
+		}
+	}
+	return labels
+}
+
+// currentLabels trả về bộ label tĩnh dùng chung (không copy), nil nếu chưa set
+func currentLabels() map[string]string {
+	if current := staticLabels.Load(); current != nil && len(*current) > 0 {
+		return *current
+	}
+	return nil
+}
+
+// formatLabels định dạng label thành chuỗi "k1=v1, k2=v2" theo thứ tự key
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, labels[k]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetLastModified khai báo thời điểm sửa đổi cuối cùng của resource trả về.
+// Handler cần gọi hàm này trước khi ghi response để LastModifiedMiddleware
+// có thể set header Last-Modified và trả về 304 khi phù hợp.
+func SetLastModified(c *gin.Context, modTime time.Time) {
+	c.Set(ContextKeyLastModified, modTime.UTC().Truncate(time.Second))
+}
+
+// LastModifiedMiddleware trả về middleware xử lý header If-Modified-Since.
+// Nếu handler đã khai báo mtime qua SetLastModified và resource chưa thay đổi
+// kể từ thời điểm client gửi lên, middleware tự động trả về 304 Not Modified
+// và bỏ qua body. Theo RFC 7232, If-Modified-Since bị bỏ qua khi request có
+// If-None-Match (để ưu tiên ETag).
+func LastModifiedMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &lastModifiedWriter{ResponseWriter: c.Writer, ctx: c}
+		c.Writer = writer
+		c.Next()
+	}
+}
+
+// lastModifiedWriter là wrapper quyết định trả về 304 tại thời điểm ghi header
+type lastModifiedWriter struct {
+	gin.ResponseWriter
+	ctx         *gin.Context
+	decided     bool
+	notModified bool
+}
+
+// decide kiểm tra mtime đã khai báo với If-Modified-Since, chỉ chạy một lần
+func (w *lastModifiedWriter) decide(code int) int {
+	if w.decided {
+		if w.notModified {
+			return http.StatusNotModified
+		}
+		return code
+	}
+	w.decided = true
+
+	value, ok := w.ctx.Get(ContextKeyLastModified)
+	if !ok || code != http.StatusOK {
+		return code
+	}
+	modTime, ok := value.(time.Time)
+	if !ok || modTime.IsZero() {
+		return code
+	}
+
+	w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+
+	req := w.ctx.Request
+	if req.Header.Get("If-None-Match") != "" {
+		return code
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil || modTime.After(since) {
+		return code
+	}
+
+	w.notModified = true
+	return http.StatusNotModified
+}
+
+// dropEntityHeaders xoá các header mô tả body vì response 304 không có body
+func (w *lastModifiedWriter) dropEntityHeaders() {
+	w.Header().Del("Content-Type")
+	w.Header().Del("Content-Length")
+}
+
+// WriteHeader thay status code bằng 304 nếu resource chưa thay đổi
+func (w *lastModifiedWriter) WriteHeader(code int) {
+	w.ResponseWriter.WriteHeader(w.decide(code))
+}
+
+// WriteHeaderNow đảm bảo quyết định 304 được áp dụng khi header được flush
+func (w *lastModifiedWriter) WriteHeaderNow() {
+	w.ResponseWriter.WriteHeader(w.decide(w.ResponseWriter.Status()))
+	if w.notModified {
+		w.dropEntityHeaders()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write bỏ qua body khi response là 304
+func (w *lastModifiedWriter) Write(b []byte) (int, error) {
+	w.ResponseWriter.WriteHeader(w.decide(w.ResponseWriter.Status()))
+	if w.notModified {
+		w.dropEntityHeaders()
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString bỏ qua body khi response là 304
+func (w *lastModifiedWriter) WriteString(s string) (int, error) {
+	w.ResponseWriter.WriteHeader(w.decide(w.ResponseWriter.Status()))
+	if w.notModified {
+		w.dropEntityHeaders()
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLastModifiedMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modTime := time.Date(2026, 10, 1, 8, 30, 15, 500, time.UTC)
+	r := gin.New()
+	r.Use(LastModifiedMiddleware())
+	r.GET("/report", func(c *gin.Context) {
+		SetLastModified(c, modTime)
+		c.JSON(http.StatusOK, gin.H{"total": 42})
+	})
+	r.GET("/live", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"total": 42}) })
+	r.POST("/report", func(c *gin.Context) {
+		SetLastModified(c, modTime)
+		c.JSON(http.StatusOK, gin.H{"total": 42})
+	})
+
+	lastModified := modTime.Format(http.TimeFormat)
+	cases := []struct {
+		name         string
+		method, path string
+		header       map[string]string
+		wantStatus   int
+		wantHeader   string
+		wantBody     bool
+	}{
+		{"không có If-Modified-Since", http.MethodGet, "/report", nil, http.StatusOK, lastModified, true},
+		{"chưa thay đổi", http.MethodGet, "/report", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified, lastModified, false},
+		{"client có bản mới hơn", http.MethodGet, "/report", map[string]string{"If-Modified-Since": modTime.Add(time.Hour).Format(http.TimeFormat)}, http.StatusNotModified, lastModified, false},
+		{"đã thay đổi", http.MethodGet, "/report", map[string]string{"If-Modified-Since": modTime.Add(-time.Second).Format(http.TimeFormat)}, http.StatusOK, lastModified, true},
+		{"If-None-Match được ưu tiên", http.MethodGet, "/report", map[string]string{"If-Modified-Since": lastModified, "If-None-Match": `"v1"`}, http.StatusOK, lastModified, true},
+		{"handler không khai báo mtime", http.MethodGet, "/live", map[string]string{"If-Modified-Since": lastModified}, http.StatusOK, "", true},
+		{"method không phải GET/HEAD", http.MethodPost, "/report", map[string]string{"If-Modified-Since": lastModified}, http.StatusOK, "", true},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		for k, v := range tc.header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.wantStatus {
+			t.Fatalf("%s: status got %d, want %d", tc.name, w.Code, tc.wantStatus)
+		}
+		if got := w.Header().Get("Last-Modified"); got != tc.wantHeader {
+			t.Fatalf("%s: Last-Modified got %q, want %q", tc.name, got, tc.wantHeader)
+		}
+		if hasBody := w.Body.Len() > 0; hasBody != tc.wantBody {
+			t.Fatalf("%s: body got %q, want body=%v", tc.name, w.Body.String(), tc.wantBody)
+		}
+		if !tc.wantBody && w.Header().Get("Content-Type") != "" {
+			t.Fatalf("%s: 304 keeps Content-Type %q", tc.name, w.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxWatchdogSamples giới hạn số latency được giữ trong một chu kỳ (reservoir sampling)
+const maxWatchdogSamples = 10000
+
+// LatencyWatchdogConfig cấu hình cho StartLatencyWatchdog
+type LatencyWatchdogConfig struct {
+	Threshold       time.Duration // Ngưỡng p99, bắt buộc
+	Interval        time.Duration // Độ dài một chu kỳ đo p99, mặc định 1 phút
+	Intervals       int           // Số chu kỳ liên tiếp vượt ngưỡng trước khi lấy profile, mặc định 5
+	ProfileDuration time.Duration // Thời gian lấy CPU profile, mặc định 10 giây
+	Cooldown        time.Duration // Khoảng cách tối thiểu giữa hai lần lấy profile, mặc định 30 phút
+
+	// Dir là thư mục lưu profile (file cpu-<thời gian>.pprof)
+	Dir string
+	// Upload nhận profile thay cho (hoặc cùng với) Dir, ví dụ đẩy lên object storage
+	Upload func(ctx context.Context, name string, profile []byte) error
+}
+
+// Validate kiểm tra tính hợp lệ của LatencyWatchdogConfig
+func (c LatencyWatchdogConfig) Validate() error {
+	var errs configErrors
+	if c.Threshold <= 0 {
+		errs.addf("Threshold must be positive, got %v", c.Threshold)
+	}
+	if c.Interval < 0 || c.ProfileDuration < 0 || c.Cooldown < 0 {
+		errs.addf("Interval/ProfileDuration/Cooldown must not be negative")
+	}
+	if c.Intervals < 0 {
+		errs.addf("Intervals must not be negative, got %d", c.Intervals)
+	}
+	if c.Dir == "" && c.Upload == nil {
+		errs.addf("Dir or Upload is required, otherwise captured profiles would be discarded")
+	}
+	return errs.err()
+}
+
+// latencyWatchdog gom latency của chu kỳ hiện tại, nhận dữ liệu qua MetricsSink
+type latencyWatchdog struct {
+	config   LatencyWatchdogConfig
+	stopped  atomic.Bool
+	mu       sync.Mutex
+	samples  []time.Duration
+	seen     int
+	breaches int
+	lastRun  time.Time
+}
+
+// StartLatencyWatchdog khởi động watchdog tự động lấy CPU profile (pprof) khi p99
+// latency vượt Threshold trong Intervals chu kỳ liên tiếp, để các đợt chậm thoáng
+// qua trên production vẫn để lại dấu vết. Watchdog nhận latency qua
+// RegisterMetricsSink nên cần LogResponseMiddleware. Trả về hàm stop, hàm này
+// dừng watchdog và bỏ đăng ký sink của nó.
+func StartLatencyWatchdog(config LatencyWatchdogConfig) (stop func()) {
+	mustValidate("LatencyWatchdog", config)
+	if config.Interval == 0 {
+		config.Interval = time.Minute
+	}
+	if config.Intervals == 0 {
+		config.Intervals = 5
+	}
+	if config.ProfileDuration == 0 {
+		config.ProfileDuration = 10 * time.Second
+	}
+	if config.Cooldown == 0 {
+		config.Cooldown = 30 * time.Minute
+	}
+
+	w := &latencyWatchdog{config: config}
+	RegisterMetricsSink(w)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if w.evaluate() {
+					go w.capture()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			w.stopped.Store(true)
+			UnregisterMetricsSink(w)
+			close(done)
+		})
+	}
+}
+
+// ObserveRequest implements MetricsSink
+func (w *latencyWatchdog) ObserveRequest(sample RequestSample) {
+	if w.stopped.Load() {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.seen++
+	if len(w.samples) < maxWatchdogSamples {
+		w.samples = append(w.samples, sample.Duration)
+	} else if i := rand.IntN(w.seen); i < maxWatchdogSamples {
+		w.samples[i] = sample.Duration
+	}
+}
+
+// evaluate kết thúc chu kỳ hiện tại và cho biết có cần lấy profile hay không
+func (w *latencyWatchdog) evaluate() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	samples := w.samples
+	w.samples, w.seen = nil, 0
+
+	if len(samples) == 0 {
+		w.breaches = 0
+		return false
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	p99 := samples[int(math.Ceil(0.99*float64(len(samples))))-1]
+
+	if p99 <= w.config.Threshold {
+		w.breaches = 0
+		return false
+	}
+	w.breaches++
+	logMessage("[WATCHDOG] p99 latency %v above %v (%d/%d intervals)", p99, w.config.Threshold, w.breaches, w.config.Intervals)
+	if w.breaches < w.config.Intervals || time.Since(w.lastRun) < w.config.Cooldown {
+		return false
+	}
+	w.breaches = 0
+	w.lastRun = time.Now()
+	return true
+}
+
+// capture lấy CPU profile trong ProfileDuration rồi lưu vào Dir và/hoặc gửi qua Upload
+func (w *latencyWatchdog) capture() {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		logMessage("[WATCHDOG] cannot start CPU profile: %v", err)
+		return
+	}
+	time.Sleep(w.config.ProfileDuration)
+	pprof.StopCPUProfile()
+
+	name := fmt.Sprintf("cpu-%s.pprof", time.Now().UTC().Format("20060102T150405Z"))
+	if w.config.Dir != "" {
+		path := filepath.Join(w.config.Dir, name)
+		if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+			logMessage("[WATCHDOG] cannot write CPU profile: %v", err)
+		} else {
+			logMessage("[WATCHDOG] CPU profile saved to %s", path)
+		}
+	}
+	if w.config.Upload != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := w.config.Upload(ctx, name, buf.Bytes()); err != nil {
+			logMessage("[WATCHDOG] cannot upload CPU profile %s: %v", name, err)
+		} else {
+			logMessage("[WATCHDOG] CPU profile %s uploaded", name)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoadSheddingConfig cấu hình cho LoadSheddingMiddleware
+type LoadSheddingConfig struct {
+	MaxInFlight   int64         // Số request xử lý đồng thời tối đa, bắt buộc
+	MinRetryAfter time.Duration // Retry-After nhỏ nhất, mặc định 1 giây
+	MaxRetryAfter time.Duration // Retry-After lớn nhất, mặc định 30 giây
+}
+
+// Validate kiểm tra tính hợp lệ của LoadSheddingConfig
+func (c LoadSheddingConfig) Validate() error {
+	var errs configErrors
+	if c.MaxInFlight <= 0 {
+		errs.addf("MaxInFlight must be positive, got %d", c.MaxInFlight)
+	}
+	if c.MinRetryAfter < 0 || c.MaxRetryAfter < 0 {
+		errs.addf("MinRetryAfter/MaxRetryAfter must not be negative")
+	}
+	if c.MinRetryAfter > 0 && c.MaxRetryAfter > 0 && c.MinRetryAfter > c.MaxRetryAfter {
+		errs.addf("MinRetryAfter (%v) is greater than MaxRetryAfter (%v)", c.MinRetryAfter, c.MaxRetryAfter)
+	}
+	return errs.err()
+}
+
+// LoadSheddingMiddleware trả về middleware từ chối (503) request khi số request
+// đang xử lý vượt MaxInFlight. Retry-After được tính theo mức quá tải hiện tại
+// và độ trễ trung bình của các request gần đây, thay vì một giá trị cố định.
+func LoadSheddingMiddleware(config LoadSheddingConfig) gin.HandlerFunc {
+	mustValidate("LoadShedding", config)
+	if config.MinRetryAfter <= 0 {
+		config.MinRetryAfter = time.Second
+	}
+	if config.MaxRetryAfter <= 0 {
+		config.MaxRetryAfter = 30 * time.Second
+	}
+
+	var inFlight int64
+	latency := &ewma{alpha: 0.2}
+
+	return func(c *gin.Context) {
+		current := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+
+		if current > config.MaxInFlight {
+			// Ước lượng thời gian để xử lý hết phần vượt tải: mỗi "lượt" MaxInFlight
+			// request mất khoảng một độ trễ trung bình
+			rounds := math.Ceil(float64(current-config.MaxInFlight) / float64(config.MaxInFlight))
+			estimate := time.Duration(rounds * latency.value())
+			abortServiceUnavailable(c, clampDuration(estimate, config.MinRetryAfter, config.MaxRetryAfter),
+				"load_shedding", fmt.Sprintf("%d requests in flight, limit %d", current, config.MaxInFlight),
+				MessageOverloaded)
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+		latency.observe(float64(time.Since(start)))
+	}
+}
+
+// abortServiceUnavailable trả về 503 kèm header Retry-After
+func abortServiceUnavailable(c *gin.Context, retryAfter time.Duration, middleware, reason string, message MessageKey) {
+	c.Header("Retry-After", strconv.Itoa(max(ceilSeconds(retryAfter), 1)))
+	AbortWithReason(c, 503, middleware, reason, gin.H{
+		"message": Message(c, message),
+	})
+}
+
+// clampDuration giới hạn d trong khoảng [lo, hi]
+func clampDuration(d, lo, hi time.Duration) time.Duration {
+	if d < lo {
+		return lo
+	}
+	if d > hi {
+		return hi
+	}
+	return d
+}
+
+// ewma là trung bình trượt luỹ thừa, an toàn cho truy cập đồng thời
+type ewma struct {
+	mu    sync.Mutex
+	alpha float64
+	avg   float64
+}
+
+// observe cập nhật giá trị trung bình với một mẫu mới
+func (e *ewma) observe(v float64) {
+	e.mu.Lock()
+	if e.avg == 0 {
+		e.avg = v
+	} else {
+		e.avg = e.alpha*v + (1-e.alpha)*e.avg
+	}
+	e.mu.Unlock()
+}
+
+// value trả về giá trị trung bình hiện tại
+func (e *ewma) value() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.avg
+}
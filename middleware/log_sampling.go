@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogSamplingConfig cấu hình cho LogSamplingMiddleware
+type LogSamplingConfig struct {
+	SampleRate float64  // Tỷ lệ request được log, trong (0, 1]; 0 là mặc định 1 (để không log path nào, dùng SkipPaths)
+	SkipPaths  []string // Path không bao giờ được log (health check, ...) trừ khi bị ép qua debug header
+
+	// DebugHeader là header ép log đầy đủ (kèm header request) và trace sampling
+	// cho một request, mặc định "X-Debug-Log". Header chỉ có hiệu lực khi request
+	// vượt qua Authorize hoặc đến từ IP trong AllowIPs.
+	DebugHeader string
+	Authorize   func(c *gin.Context) bool // Kiểm tra quyền dùng debug header (ví dụ token nội bộ)
+	AllowIPs    *ReloadingList            // IP được phép dùng debug header
+}
+
+// Validate kiểm tra tính hợp lệ của LogSamplingConfig
+func (c LogSamplingConfig) Validate() error {
+	var errs configErrors
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		errs.addf("SampleRate must be within (0, 1] (or 0 for the default 1), got %v", c.SampleRate)
+	}
+	errs.checkExactPaths("SkipPaths", c.SkipPaths)
+	errs.checkHeaderName("DebugHeader", c.DebugHeader)
+	if c.DebugHeader != "" && c.Authorize == nil && c.AllowIPs == nil {
+		errs.addf("DebugHeader %q requires Authorize or AllowIPs, otherwise any client could force full logging", c.DebugHeader)
+	}
+	return errs.err()
+}
+
+// LogSamplingMiddleware trả về middleware quyết định request có được log hay không
+// (theo SkipPaths và SampleRate). Cần đặt trước LogRequestMiddleware và
+// LogResponseMiddleware. Metrics vẫn được ghi nhận cho mọi request. Quyết định bỏ
+// log chỉ có hiệu lực khi request thành công: request kết thúc bằng 5xx hoặc panic
+// vẫn được log kèm body và header (đánh dấu Escalated).
+//
+// Request có debug header hợp lệ luôn được log đầy đủ kèm header, và cờ sampled
+// của header traceparent (W3C) được bật để tracing phía sau cũng lấy mẫu request đó.
+// Debug header chỉ có hiệu lực khi có Authorize hoặc AllowIPs.
+func LogSamplingMiddleware(config LogSamplingConfig) gin.HandlerFunc {
+	mustValidate("LogSampling", config)
+	if config.SampleRate == 0 {
+		config.SampleRate = 1
+	}
+	guarded := config.Authorize != nil || config.AllowIPs != nil
+	if config.DebugHeader == "" && guarded {
+		config.DebugHeader = "X-Debug-Log"
+	}
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if guarded && c.GetHeader(config.DebugHeader) != "" && debugAllowed(c, config) {
+			c.Set(ContextKeyLogForced, true)
+			c.Set(ContextKeyLogSampled, true)
+			if tp := c.GetHeader("traceparent"); tp != "" {
+				c.Request.Header.Set("traceparent", forceTraceSampled(tp))
+			}
+			c.Next()
+			return
+		}
+
+		sampled := !skip[c.Request.URL.Path] && (config.SampleRate >= 1 || rand.Float64() < config.SampleRate)
+		c.Set(ContextKeyLogSampled, sampled)
+		c.Next()
+	}
+}
+
+// debugAllowed kiểm tra request có quyền dùng debug header
+func debugAllowed(c *gin.Context, config LogSamplingConfig) bool {
+	if config.AllowIPs != nil && config.AllowIPs.ContainsIP(c.ClientIP()) {
+		return true
+	}
+	return config.Authorize != nil && config.Authorize(c)
+}
+
+// LogSampled cho biết request hiện tại có được log hay không.
+// Mặc định là true khi không dùng LogSamplingMiddleware.
+func LogSampled(c *gin.Context) bool {
+	sampled, ok := c.Get(ContextKeyLogSampled)
+	return !ok || sampled == true
+}
+
+// LogForced cho biết request hiện tại được ép log đầy đủ qua debug header
+func LogForced(c *gin.Context) bool {
+	return c.GetBool(ContextKeyLogForced)
+}
+
+// forceTraceSampled bật cờ sampled trong header traceparent
+// ("version-traceid-parentid-flags"), giữ nguyên nếu header không đúng định dạng
+func forceTraceSampled(traceparent string) string {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[3]) != 2 {
+		return traceparent
+	}
+	var flags byte
+	for _, ch := range []byte(parts[3]) {
+		switch {
+		case ch >= '0' && ch <= '9':
+			flags = flags<<4 | (ch - '0')
+		case ch >= 'a' && ch <= 'f':
+			flags = flags<<4 | (ch - 'a' + 10)
+		default:
+			return traceparent
+		}
+	}
+	const hex = "0123456789abcdef"
+	flags |= 0x01
+	parts[3] = string([]byte{hex[flags>>4], hex[flags&0x0f]})
+	return strings.Join(parts, "-")
+}
+
+// sensitiveHeaders là các header bị che khi log header của request
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"X-Api-Key":           true,
+}
+
+// formatLogHeaders trả về header của request theo thứ tự tên, che giá trị các header nhạy cảm
+func formatLogHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(header[name], ",")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			value = "[REDACTED]"
+		}
+		parts = append(parts, name+"="+value)
+	}
+	return strings.Join(parts, "; ")
+}
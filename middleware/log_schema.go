@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+)
+
+// LogSchema selects the JSON field layout of records written by FileLogger and DefaultLogger
+type LogSchema string
+
+const (
+	// LogSchemaDefault writes the package's own record layout (type, entry, ...)
+	LogSchemaDefault LogSchema = ""
+	// LogSchemaEnvoy writes one flat record per completed request using the
+	// field names of Envoy's default JSON access log (as emitted by Istio), so
+	// application logs merge into the same dashboards and queries as the mesh's
+	// own access logs. Request entries are not written, since the completed
+	// request record already carries them; errors and messages are written as
+	// flat records keyed by request_id.
+	LogSchemaEnvoy LogSchema = "envoy"
+	// LogSchemaNginx is like LogSchemaEnvoy but names fields after the nginx
+	// variables of the same meaning (remote_addr, request_uri, status,
+	// request_time, ...), matching the usual JSON log_format with escape=json.
+	// nginx has no built-in JSON format, so queries may need the field names of
+	// the deployment's own log_format mapped onto these.
+	LogSchemaNginx LogSchema = "nginx"
+)
+
+// validate reports an unknown schema
+func (s LogSchema) validate() error {
+	switch s {
+	case LogSchemaDefault, LogSchemaEnvoy, LogSchemaNginx:
+		return nil
+	}
+	return fmt.Errorf("unknown log schema %q", string(s))
+}
+
+// schemaRecord converts a record to the layout of an access log schema,
+// returning nil for records that are not written in that schema
+func schemaRecord(schema LogSchema, now time.Time, record LogRecord) interface{} {
+	if schema == LogSchemaNginx {
+		return nginxRecord(now, record)
+	}
+	return envoyRecord(now, record)
+}
+
+// envoyAccessLogRecord is a completed request in Envoy's default JSON access log layout
+type envoyAccessLogRecord struct {
+	StartTime               string            `json:"start_time"`
+	Method                  string            `json:"method"`
+	Path                    string            `json:"path"`
+	Protocol                string            `json:"protocol"`
+	ResponseCode            int               `json:"response_code"`
+	ResponseFlags           string            `json:"response_flags"`
+	BytesReceived           int64             `json:"bytes_received"`
+	BytesSent               int64             `json:"bytes_sent"`
+	Duration                int64             `json:"duration"`
+	XForwardedFor           string            `json:"x_forwarded_for"`
+	UserAgent               string            `json:"user_agent"`
+	RequestID               string            `json:"request_id"`
+	Authority               string            `json:"authority"`
+	DownstreamRemoteAddress string            `json:"downstream_remote_address"`
+	AbortedBy               string            `json:"aborted_by,omitempty"`
+	AbortReason             string            `json:"abort_reason,omitempty"`
+	Labels                  map[string]string `json:"labels,omitempty"`
+}
+
+// envoyEventRecord is an error or message written alongside Envoy access log records
+type envoyEventRecord struct {
+	StartTime string `json:"start_time"`
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// envoyRecord converts a record to the Envoy layout, returning nil for records
+// that are not written in this schema
+func envoyRecord(now time.Time, record LogRecord) interface{} {
+	switch record.Kind {
+	case LogKindResponse:
+		entry := record.Entry
+		return envoyAccessLogRecord{
+			StartTime:               formatEnvoyTime(entryStart(now, *entry)),
+			Method:                  entry.Method,
+			Path:                    entryURI(*entry),
+			Protocol:                accessLogValue(entry.Protocol),
+			ResponseCode:            entry.StatusCode,
+			ResponseFlags:           envoyResponseFlags(*entry),
+			BytesReceived:           entry.BytesReceived,
+			BytesSent:               entry.BytesSent,
+			Duration:                entry.ProcessTime.Milliseconds(),
+			XForwardedFor:           accessLogValue(entry.XForwardedFor),
+			UserAgent:               accessLogValue(entry.UserAgent),
+			RequestID:               accessLogValue(entry.RequestID),
+			Authority:               accessLogValue(entry.Authority),
+			DownstreamRemoteAddress: accessLogValue(entry.ClientIP),
+			AbortedBy:               entry.AbortedBy,
+			AbortReason:             entry.AbortReason,
+			Labels:                  entry.Labels,
+		}
+	case LogKindError:
+		r := envoyEventRecord{StartTime: formatEnvoyTime(now), RequestID: record.RequestID}
+		if record.Err != nil {
+			r.Error = record.Err.Error()
+		}
+		return r
+	case LogKindMessage:
+		return envoyEventRecord{StartTime: formatEnvoyTime(now), Message: record.Message}
+	}
+	return nil
+}
+
+// nginxAccessLogRecord is a completed request with fields named after nginx variables
+type nginxAccessLogRecord struct {
+	TimeISO8601       string            `json:"time_iso8601"`
+	RemoteAddr        string            `json:"remote_addr"`
+	RequestMethod     string            `json:"request_method"`
+	RequestURI        string            `json:"request_uri"`
+	ServerProtocol    string            `json:"server_protocol"`
+	Status            int               `json:"status"`
+	BodyBytesSent     int64             `json:"body_bytes_sent"`
+	RequestTime       float64           `json:"request_time"`
+	HTTPUserAgent     string            `json:"http_user_agent"`
+	HTTPXForwardedFor string            `json:"http_x_forwarded_for"`
+	Host              string            `json:"host"`
+	RequestID         string            `json:"request_id"`
+	AbortedBy         string            `json:"aborted_by,omitempty"`
+	AbortReason       string            `json:"abort_reason,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+}
+
+// nginxEventRecord is an error or message written alongside nginx access log records
+type nginxEventRecord struct {
+	TimeISO8601 string `json:"time_iso8601"`
+	RequestID   string `json:"request_id,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// nginxRecord converts a record to the nginx layout, returning nil for records
+// that are not written in this schema
+func nginxRecord(now time.Time, record LogRecord) interface{} {
+	switch record.Kind {
+	case LogKindResponse:
+		entry := record.Entry
+		return nginxAccessLogRecord{
+			// Like nginx, the time is when the request completed
+			TimeISO8601:       formatNginxTime(entryStart(now, *entry).Add(entry.ProcessTime)),
+			RemoteAddr:        accessLogValue(entry.ClientIP),
+			RequestMethod:     entry.Method,
+			RequestURI:        entryURI(*entry),
+			ServerProtocol:    accessLogValue(entry.Protocol),
+			Status:            entry.StatusCode,
+			BodyBytesSent:     entry.BytesSent,
+			RequestTime:       float64(entry.ProcessTime.Milliseconds()) / 1000,
+			HTTPUserAgent:     accessLogValue(entry.UserAgent),
+			HTTPXForwardedFor: accessLogValue(entry.XForwardedFor),
+			Host:              accessLogValue(entry.Authority),
+			RequestID:         accessLogValue(entry.RequestID),
+			AbortedBy:         entry.AbortedBy,
+			AbortReason:       entry.AbortReason,
+			Labels:            entry.Labels,
+		}
+	case LogKindError:
+		r := nginxEventRecord{TimeISO8601: formatNginxTime(now), RequestID: record.RequestID}
+		if record.Err != nil {
+			r.Error = record.Err.Error()
+		}
+		return r
+	case LogKindMessage:
+		return nginxEventRecord{TimeISO8601: formatNginxTime(now), Message: record.Message}
+	}
+	return nil
+}
+
+// entryStart returns when the request started, estimating it from now for
+// entries built without a start time
+func entryStart(now time.Time, entry LogEntry) time.Time {
+	if !entry.StartTime.IsZero() {
+		return entry.StartTime
+	}
+	return now.Add(-entry.ProcessTime)
+}
+
+// entryURI returns the request URI including the query, falling back to the path
+func entryURI(entry LogEntry) string {
+	if entry.URI != "" {
+		return entry.URI
+	}
+	return entry.Path
+}
+
+// envoyResponseFlags maps entry state to Envoy response flags, "-" when none apply
+func envoyResponseFlags(entry LogEntry) string {
+	switch {
+	case entry.ClientGone:
+		return "DC" // downstream connection termination
+	case entry.AbortedBy == "rate_limit":
+		return "RL" // rate limited
+	}
+	return "-"
+}
+
+// accessLogValue renders empty values as "-" like Envoy and nginx do
+func accessLogValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// formatEnvoyTime formats a time like Envoy's %START_TIME% (RFC 3339, milliseconds, UTC)
+func formatEnvoyTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// formatNginxTime formats a time like nginx's $time_iso8601 (seconds, UTC offset)
+func formatNginxTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05-07:00")
+}
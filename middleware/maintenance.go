@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maintenanceState lưu trạng thái maintenance mode toàn cục
+var maintenanceState struct {
+	mu      sync.RWMutex
+	enabled bool
+	until   time.Time
+}
+
+// EnableMaintenance bật maintenance mode. until là thời điểm dự kiến kết thúc,
+// dùng để tính Retry-After; truyền time.Time{} nếu chưa biết.
+func EnableMaintenance(until time.Time) {
+	maintenanceState.mu.Lock()
+	maintenanceState.enabled = true
+	maintenanceState.until = until
+	maintenanceState.mu.Unlock()
+}
+
+// DisableMaintenance tắt maintenance mode
+func DisableMaintenance() {
+	maintenanceState.mu.Lock()
+	maintenanceState.enabled = false
+	maintenanceState.until = time.Time{}
+	maintenanceState.mu.Unlock()
+}
+
+// MaintenanceConfig cấu hình cho MaintenanceMiddleware
+type MaintenanceConfig struct {
+	DefaultRetryAfter time.Duration // Retry-After khi không biết thời điểm kết thúc, mặc định 60 giây
+	MaxRetryAfter     time.Duration // Retry-After lớn nhất, mặc định 1 giờ
+	BypassPaths       []string      // Các path vẫn được phục vụ (ví dụ health check)
+}
+
+// Validate kiểm tra tính hợp lệ của MaintenanceConfig
+func (c MaintenanceConfig) Validate() error {
+	var errs configErrors
+	if c.DefaultRetryAfter < 0 || c.MaxRetryAfter < 0 {
+		errs.addf("DefaultRetryAfter/MaxRetryAfter must not be negative")
+	}
+	errs.checkExactPaths("BypassPaths", c.BypassPaths)
+	return errs.err()
+}
+
+// MaintenanceMiddleware trả về middleware từ chối (503) mọi request khi
+// maintenance mode đang bật. Retry-After được tính từ thời điểm kết thúc
+// đã khai báo trong EnableMaintenance.
+func MaintenanceMiddleware(config MaintenanceConfig) gin.HandlerFunc {
+	mustValidate("Maintenance", config)
+	if config.DefaultRetryAfter <= 0 {
+		config.DefaultRetryAfter = time.Minute
+	}
+	if config.MaxRetryAfter <= 0 {
+		config.MaxRetryAfter = time.Hour
+	}
+	bypass := make(map[string]bool, len(config.BypassPaths))
+	for _, p := range config.BypassPaths {
+		bypass[p] = true
+	}
+
+	return func(c *gin.Context) {
+		maintenanceState.mu.RLock()
+		enabled, until := maintenanceState.enabled, maintenanceState.until
+		maintenanceState.mu.RUnlock()
+
+		if !enabled || bypass[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		retryAfter := config.DefaultRetryAfter
+		if !until.IsZero() {
+			retryAfter = time.Until(until)
+		}
+		abortServiceUnavailable(c, clampDuration(retryAfter, time.Second, config.MaxRetryAfter),
+			"maintenance", "maintenance mode enabled", MessageMaintenance)
+	}
+}
@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MessageKey định danh một thông báo lỗi trả về cho người dùng
+type MessageKey string
+
+// Các thông báo lỗi do package trả về, bản dịch đăng ký qua RegisterMessages
+const (
+	MessageInternalError     MessageKey = "internal_error"      // 500, panic hoặc lỗi transaction
+	MessageTooManyRequests   MessageKey = "too_many_requests"   // 429, vượt rate limit
+	MessageTooManyConcurrent MessageKey = "too_many_concurrent" // 429, vượt giới hạn đồng thời
+	MessageOverloaded        MessageKey = "service_overloaded"  // 503, load shedding
+	MessageMaintenance       MessageKey = "service_maintenance" // 503, maintenance mode
+	MessageBreakerOpen       MessageKey = "breaker_open"        // 503, circuit breaker tới upstream đang mở
+	MessageValidationFailed  MessageKey = "validation_failed"   // 422, request không hợp lệ
+	MessageForbidden         MessageKey = "forbidden"           // 403, IP/bot bị chặn
+	MessageOutsideSchedule   MessageKey = "outside_schedule"    // 403, ngoài khung giờ cho phép
+	MessageBadRequest        MessageKey = "bad_request"         // 400, header bất thường
+	MessageHeadersTooLarge   MessageKey = "headers_too_large"   // 431, vượt giới hạn header
+)
+
+// DefaultLocale là locale của các thông báo mặc định
+const DefaultLocale = "en"
+
+// defaultMessages là thông báo tiếng Anh, dùng khi locale không có bản dịch
+var defaultMessages = map[MessageKey]string{
+	MessageInternalError:     "Internal Server Error. Please try again later.",
+	MessageTooManyRequests:   "Too Many Requests",
+	MessageTooManyConcurrent: "Too many concurrent requests",
+	MessageOverloaded:        "Service is overloaded. Please try again later.",
+	MessageMaintenance:       "Service is under maintenance. Please try again later.",
+	MessageBreakerOpen:       "Service is temporarily unavailable. Please try again later.",
+	MessageValidationFailed:  "The request is invalid.",
+	MessageForbidden:         "Forbidden",
+	MessageOutsideSchedule:   "This endpoint is not available at this time.",
+	MessageBadRequest:        "Bad Request",
+	MessageHeadersTooLarge:   "Request Header Fields Too Large",
+}
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = make(map[string]map[MessageKey]string)
+)
+
+// RegisterMessages đăng ký (hoặc bổ sung) bản dịch cho locale, ví dụ
+//
+//	middleware.RegisterMessages("vi", map[middleware.MessageKey]string{
+//	    middleware.MessageTooManyRequests: "Quá nhiều yêu cầu",
+//	})
+//
+// Key không có bản dịch dùng thông báo tiếng Anh mặc định.
+func RegisterMessages(locale string, messages map[MessageKey]string) {
+	locale = normalizeLocale(locale)
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	translations, ok := catalog[locale]
+	if !ok {
+		translations = make(map[MessageKey]string, len(messages))
+		catalog[locale] = translations
+	}
+	for key, message := range messages {
+		translations[key] = message
+	}
+}
+
+// Message trả về thông báo theo locale của request (xem LocaleMiddleware): thử
+// locale đầy đủ ("vi-vn"), rồi ngôn ngữ gốc ("vi"), cuối cùng là tiếng Anh
+func Message(c *gin.Context, key MessageKey) string {
+	return lookupMessage(Locale(c), key)
+}
+
+// lookupMessage tra thông báo của key theo locale
+func lookupMessage(locale string, key MessageKey) string {
+	if locale != "" {
+		catalogMu.RLock()
+		message, ok := catalog[locale][key]
+		if !ok {
+			message, ok = catalog[baseLanguage(locale)][key]
+		}
+		catalogMu.RUnlock()
+		if ok {
+			return message
+		}
+	}
+	if message, ok := defaultMessages[key]; ok {
+		return message
+	}
+	return string(key)
+}
+
+// Locale trả về locale của request do LocaleMiddleware xác định, rỗng nếu middleware chưa chạy
+func Locale(c *gin.Context) string {
+	return c.GetString(ContextKeyLocale)
+}
+
+// LocaleConfig cấu hình cho LocaleMiddleware
+type LocaleConfig struct {
+	// Supported là các locale ứng dụng hỗ trợ; rỗng là DefaultLocale cùng mọi
+	// locale đã đăng ký qua RegisterMessages tại thời điểm request
+	Supported []string
+	// Default là locale khi Accept-Language không khớp locale nào, mặc định DefaultLocale
+	Default string
+}
+
+// Validate kiểm tra tính hợp lệ của LocaleConfig
+func (c LocaleConfig) Validate() error {
+	var errs configErrors
+	for _, locale := range c.Supported {
+		if strings.TrimSpace(locale) == "" {
+			errs.addf("Supported must not contain empty locales")
+			break
+		}
+	}
+	return errs.err()
+}
+
+// LocaleMiddleware trả về middleware xác định locale của request từ header
+// Accept-Language (theo q-value) trong các locale được hỗ trợ và lưu vào
+// context (xem Locale). Các thông báo lỗi của package dùng locale này.
+func LocaleMiddleware(config LocaleConfig) gin.HandlerFunc {
+	mustValidate("Locale", config)
+	if config.Default == "" {
+		config.Default = DefaultLocale
+	}
+	config.Default = normalizeLocale(config.Default)
+	supported := make([]string, len(config.Supported))
+	for i, locale := range config.Supported {
+		supported[i] = normalizeLocale(locale)
+	}
+
+	return func(c *gin.Context) {
+		candidates := supported
+		if len(candidates) == 0 {
+			candidates = registeredLocales()
+		}
+		locale := matchLocale(c.GetHeader("Accept-Language"), candidates)
+		if locale == "" {
+			locale = config.Default
+		}
+		c.Set(ContextKeyLocale, locale)
+		c.Next()
+	}
+}
+
+// registeredLocales trả về DefaultLocale cùng các locale đã đăng ký bản dịch
+func registeredLocales() []string {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	locales := make([]string, 0, len(catalog)+1)
+	locales = append(locales, DefaultLocale)
+	for locale := range catalog {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// matchLocale chọn locale hỗ trợ khớp tốt nhất với Accept-Language: mỗi tag theo
+// thứ tự ưu tiên được so khớp chính xác trước, sau đó theo ngôn ngữ gốc
+func matchLocale(header string, supported []string) string {
+	for _, tag := range parseAcceptLanguage(header) {
+		if tag == "*" && len(supported) > 0 {
+			return supported[0]
+		}
+		for _, locale := range supported {
+			if locale == tag {
+				return locale
+			}
+		}
+		for _, locale := range supported {
+			if baseLanguage(locale) == baseLanguage(tag) {
+				return locale
+			}
+		}
+	}
+	return ""
+}
+
+// parseAcceptLanguage trả về các tag ngôn ngữ sắp xếp theo q-value giảm dần,
+// bỏ qua tag có q=0
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = normalizeLocale(tag)
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// normalizeLocale đưa locale về dạng chữ thường, dùng "-" làm dấu phân cách
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// baseLanguage trả về ngôn ngữ gốc của locale, ví dụ "vi" cho "vi-vn"
+func baseLanguage(locale string) string {
+	base, _, _ := strings.Cut(locale, "-")
+	return base
+}
+
+// AbortWithValidationError dừng chain với 422 và thông báo MessageValidationFailed
+// theo locale của request; details (ví dụ danh sách field lỗi) được trả kèm
+// trong field "errors" nếu khác nil.
+func AbortWithValidationError(c *gin.Context, middleware, reason string, details interface{}) {
+	body := gin.H{"message": Message(c, MessageValidationFailed)}
+	if details != nil {
+		body["errors"] = details
+	}
+	AbortWithReason(c, http.StatusUnprocessableEntity, middleware, reason, body)
+}
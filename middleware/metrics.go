@@ -5,19 +5,44 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/kimxuanhong/go-middleware/store"
 )
 
 // Metrics tracks request statistics
 type Metrics struct {
-	TotalRequests    uint64
-	ErrorCount       uint64
-	TotalLatency     uint64
-	MinLatency       uint64
-	MaxLatency       uint64
-	MethodCounts     map[string]uint64
-	StatusCodeCounts map[int]uint64
-	mu               sync.RWMutex
-	TotalDuration    uint64
+	TotalRequests     uint64
+	ErrorCount        uint64
+	TotalLatency      uint64
+	MinLatency        uint64
+	MaxLatency        uint64
+	MethodCounts      map[string]uint64
+	StatusCodeCounts  map[int]uint64
+	mu                sync.RWMutex
+	TotalDuration     uint64
+	HoneypotHits      uint64
+	TxCommits         uint64
+	TxRollbacks       uint64
+	TxFailures        uint64
+	TxDuration        uint64
+	HooksRun          uint64
+	HooksFailed       uint64
+	HooksPanicked     uint64
+	HooksSkipped      uint64
+	ClientDisconnects uint64
+	stores            map[string]store.StatsProvider
+	routeStats        map[string]*routeStat
+	clientVersions    map[string]map[string]uint64
+	botCounts         map[BotClass]uint64
+
+	// HeaderCountRejections/HeaderBytesRejections đếm request bị từ chối (431)
+	// do vượt số header hoặc tổng kích thước header
+	HeaderCountRejections uint64
+	HeaderBytesRejections uint64
+
+	// TxCommitFailures đếm transaction commit lỗi, tách khỏi TxRollbacks để phân
+	// biệt với rollback chủ động (4xx/5xx, dry-run)
+	TxCommitFailures uint64
 }
 
 // NewMetrics creates a new Metrics instance
@@ -70,8 +95,25 @@ func (m *Metrics) RecordRequest(method string, statusCode int, latency time.Dura
 	m.mu.Unlock()
 }
 
-// GetMetrics returns a copy of the current metrics
+// RegisterStore adds a store whose size/eviction stats are reported under "stores"
+func (m *Metrics) RegisterStore(name string, s store.StatsProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stores == nil {
+		m.stores = make(map[string]store.StatsProvider)
+	}
+	m.stores[name] = s
+}
+
+// GetMetrics returns a copy of the current metrics, with the stats of the
+// default logger under "logger"
 func (m *Metrics) GetMetrics() map[string]interface{} {
+	return m.snapshot(defaultLogger)
+}
+
+// snapshot returns a copy of the current metrics, with the stats of logger
+// under "logger" when it implements LoggerStats
+func (m *Metrics) snapshot(logger Logger) map[string]interface{} {
 	m.mu.RLock()
 	methodCounts := make(map[string]uint64, len(m.MethodCounts))
 	for k, v := range m.MethodCounts {
@@ -81,14 +123,62 @@ func (m *Metrics) GetMetrics() map[string]interface{} {
 	for k, v := range m.StatusCodeCounts {
 		statusCodeCounts[k] = v
 	}
+	routeHits := make(map[string]routeStat, len(m.routeStats))
+	for k, v := range m.routeStats {
+		routeHits[k] = *v
+	}
+	clientVersions := make(map[string]map[string]uint64, len(m.clientVersions))
+	for route, versions := range m.clientVersions {
+		counts := make(map[string]uint64, len(versions))
+		for v, n := range versions {
+			counts[v] = n
+		}
+		clientVersions[route] = counts
+	}
+	botCounts := make(map[BotClass]uint64, len(m.botCounts))
+	for k, v := range m.botCounts {
+		botCounts[k] = v
+	}
+	storeStats := make(map[string]store.Stats, len(m.stores))
+	for name, s := range m.stores {
+		storeStats[name] = s.Stats()
+	}
 	m.mu.RUnlock()
 
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"total_requests":      atomic.LoadUint64(&m.TotalRequests),
 		"method_counts":       methodCounts,
 		"status_code_counts":  statusCodeCounts,
 		"average_duration_ms": atomic.LoadUint64(&m.TotalDuration) / (atomic.LoadUint64(&m.TotalRequests) + 1), // tránh chia 0
+		"honeypot_hits":       atomic.LoadUint64(&m.HoneypotHits),
+		"client_disconnects":  atomic.LoadUint64(&m.ClientDisconnects),
+		"stores":              storeStats,
+		"route_hits":          routeHits,
+		"client_versions":     clientVersions,
+		"bots":                botShare(botCounts),
+		"labels":              StaticLabels(),
+		"header_limit_rejections": map[string]uint64{
+			"count": atomic.LoadUint64(&m.HeaderCountRejections),
+			"bytes": atomic.LoadUint64(&m.HeaderBytesRejections),
+		},
+		"transactions": map[string]uint64{
+			"commits":         atomic.LoadUint64(&m.TxCommits),
+			"rollbacks":       atomic.LoadUint64(&m.TxRollbacks),
+			"failures":        atomic.LoadUint64(&m.TxFailures),
+			"commit_failures": atomic.LoadUint64(&m.TxCommitFailures),
+			"duration_ms":     atomic.LoadUint64(&m.TxDuration),
+		},
+		"after_success_hooks": map[string]uint64{
+			"run":      atomic.LoadUint64(&m.HooksRun),
+			"failed":   atomic.LoadUint64(&m.HooksFailed),
+			"panicked": atomic.LoadUint64(&m.HooksPanicked),
+			"skipped":  atomic.LoadUint64(&m.HooksSkipped),
+		},
+	}
+	if s, ok := logger.(LoggerStats); ok {
+		result["logger"] = s.LoggerStats()
 	}
+	return result
 }
 
 // PrintMetrics prints the current metrics to stdout
@@ -98,6 +188,7 @@ func (m *Metrics) PrintMetrics() {
 	fmt.Println("\n=== Server Metrics ===")
 	fmt.Printf("Total Requests: %d\n", metrics["total_requests"])
 	fmt.Printf("Average Duration (ms): %d\n", metrics["average_duration_ms"])
+	fmt.Printf("Honeypot Hits: %d\n", metrics["honeypot_hits"])
 
 	fmt.Println("\nRequests by Method:")
 	if methodCounts, ok := metrics["method_counts"].(map[string]uint64); ok {
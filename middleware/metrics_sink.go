@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RequestSample là số liệu của một request được gửi tới các MetricsSink
+type RequestSample struct {
+	Method     string
+	Route      string // Route pattern (c.FullPath()), rỗng với route không được đăng ký
+	StatusCode int
+	Duration   time.Duration
+	Labels     map[string]string // Label tĩnh của deployment
+}
+
+// MetricsSink nhận số liệu của từng request để đẩy sang hệ thống monitoring
+// (Prometheus, StatsD, OTLP, log, ...). ObserveRequest được gọi đồng bộ trên
+// luồng xử lý request nên cần nhanh và không block.
+type MetricsSink interface {
+	ObserveRequest(sample RequestSample)
+}
+
+// MetricsSinkFunc cho phép dùng một hàm như MetricsSink
+type MetricsSinkFunc func(sample RequestSample)
+
+// ObserveRequest implements MetricsSink
+func (f MetricsSinkFunc) ObserveRequest(sample RequestSample) {
+	f(sample)
+}
+
+var (
+	sinksMu      sync.Mutex
+	metricsSinks atomic.Pointer[[]MetricsSink]
+)
+
+// RegisterMetricsSink đăng ký thêm sink nhận số liệu request từ LogResponseMiddleware.
+// Có thể đăng ký nhiều sink cùng lúc (ví dụ StatsD và Prometheus trong giai đoạn chuyển đổi);
+// snapshot nội bộ (GetMetrics) vẫn được ghi nhận độc lập với các sink.
+func RegisterMetricsSink(sinks ...MetricsSink) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	var current []MetricsSink
+	if p := metricsSinks.Load(); p != nil {
+		current = *p
+	}
+	updated := append(append([]MetricsSink(nil), current...), sinks...)
+	metricsSinks.Store(&updated)
+}
+
+// UnregisterMetricsSink bỏ đăng ký các sink đã đăng ký bằng RegisterMetricsSink.
+// Sink được so sánh bằng ==, nên chỉ sink so sánh được (ví dụ con trỏ) mới bỏ
+// đăng ký được; MetricsSinkFunc bị bỏ qua.
+func UnregisterMetricsSink(sinks ...MetricsSink) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	p := metricsSinks.Load()
+	if p == nil {
+		return
+	}
+	updated := make([]MetricsSink, 0, len(*p))
+	for _, sink := range *p {
+		if !containsSink(sinks, sink) {
+			updated = append(updated, sink)
+		}
+	}
+	metricsSinks.Store(&updated)
+}
+
+// containsSink kiểm tra sink có trong sinks, bỏ qua sink không so sánh được
+func containsSink(sinks []MetricsSink, sink MetricsSink) bool {
+	if sink == nil || !reflect.TypeOf(sink).Comparable() {
+		return false
+	}
+	for _, s := range sinks {
+		if s != nil && reflect.TypeOf(s).Comparable() && s == sink {
+			return true
+		}
+	}
+	return false
+}
+
+// ResetMetricsSinks bỏ đăng ký tất cả các sink
+func ResetMetricsSinks() {
+	sinksMu.Lock()
+	metricsSinks.Store(nil)
+	sinksMu.Unlock()
+}
+
+// emitRequestSample gửi sample tới các sink đã đăng ký; panic trong một sink
+// được log và không ảnh hưởng tới request hay các sink khác
+func emitRequestSample(sample RequestSample) {
+	p := metricsSinks.Load()
+	if p == nil {
+		return
+	}
+	for _, sink := range *p {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logMessage("[METRICS] sink %T panicked: %v", sink, r)
+				}
+			}()
+			sink.ObserveRequest(sample)
+		}()
+	}
+}
+
+// LogMetricsSink ghi mỗi sample thành một dòng log dạng key=value, dùng cho
+// các hệ thống trích metrics từ log (CloudWatch metric filter, Loki, ...)
+type LogMetricsSink struct{}
+
+// ObserveRequest implements MetricsSink
+func (LogMetricsSink) ObserveRequest(sample RequestSample) {
+	logMessage("[METRIC] http_request method=%s route=%s status=%d duration_ms=%.2f%s",
+		sample.Method, sample.Route, sample.StatusCode,
+		float64(sample.Duration.Microseconds())/1000.0, formatMetricLabels(sample.Labels))
+}
+
+// formatMetricLabels trả về chuỗi " key=value" của label theo thứ tự tên
+func formatMetricLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%s", k, labels[k])
+	}
+	return b.String()
+}
+
+// StatsDConfig cấu hình cho NewStatsDSink
+type StatsDConfig struct {
+	Address string // Địa chỉ UDP của StatsD agent, mặc định "127.0.0.1:8125"
+	Prefix  string // Tiền tố tên metric, ví dụ "myservice"
+	// Tags gửi method/route/status và label dưới dạng tag DogStatsD ("|#k:v");
+	// nếu false, method và status được đưa vào tên metric
+	Tags bool
+}
+
+// Validate kiểm tra tính hợp lệ của StatsDConfig
+func (c StatsDConfig) Validate() error {
+	var errs configErrors
+	if c.Address != "" {
+		if _, _, err := net.SplitHostPort(c.Address); err != nil {
+			errs.addf("Address: %v", err)
+		}
+	}
+	return errs.err()
+}
+
+// StatsDSink gửi số liệu request tới StatsD qua UDP
+type StatsDSink struct {
+	config StatsDConfig
+	conn   net.Conn
+}
+
+// NewStatsDSink tạo StatsDSink; gói tin UDP bị mất không ảnh hưởng tới request
+func NewStatsDSink(config StatsDConfig) (*StatsDSink, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	if config.Address == "" {
+		config.Address = "127.0.0.1:8125"
+	}
+	conn, err := net.Dial("udp", config.Address)
+	if err != nil {
+		return nil, err
+	}
+	if config.Prefix != "" && !strings.HasSuffix(config.Prefix, ".") {
+		config.Prefix += "."
+	}
+	return &StatsDSink{config: config, conn: conn}, nil
+}
+
+// ObserveRequest implements MetricsSink
+func (s *StatsDSink) ObserveRequest(sample RequestSample) {
+	durationMs := strconv.FormatFloat(float64(sample.Duration.Microseconds())/1000.0, 'f', 2, 64)
+	status := strconv.Itoa(sample.StatusCode)
+
+	var payload string
+	if s.config.Tags {
+		tags := []string{"method:" + sample.Method, "route:" + sample.Route, "status:" + status}
+		for k, v := range sample.Labels {
+			tags = append(tags, k+":"+v)
+		}
+		sort.Strings(tags[3:])
+		suffix := "|#" + strings.Join(tags, ",")
+		payload = s.config.Prefix + "http.requests:1|c" + suffix + "\n" +
+			s.config.Prefix + "http.duration:" + durationMs + "|ms" + suffix
+	} else {
+		name := s.config.Prefix + "http." + strings.ToLower(sample.Method) + "." + status
+		payload = name + ".requests:1|c\n" + name + ".duration:" + durationMs + "|ms"
+	}
+	_, _ = s.conn.Write([]byte(payload))
+}
+
+// Close đóng kết nối UDP
+func (s *StatsDSink) Close() error {
+	return s.conn.Close()
+}
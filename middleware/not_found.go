@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotFoundAggregationConfig cấu hình gộp log 404 cho các path không được đăng ký
+type NotFoundAggregationConfig struct {
+	Interval time.Duration // Chu kỳ ghi log tổng hợp, mặc định 1 phút
+	TopN     int           // Số path/IP nhiều nhất được liệt kê, mặc định 10
+	// MaxKeys là số path (và số IP) khác nhau tối đa được đếm riêng mỗi chu kỳ, để
+	// scanner gửi path ngẫu nhiên không làm bộ nhớ tăng không giới hạn; hit của
+	// path/IP mới khi đã đủ chỉ được đếm vào số overflow. Mặc định 10000.
+	MaxKeys int
+}
+
+// Validate kiểm tra tính hợp lệ của NotFoundAggregationConfig
+func (c NotFoundAggregationConfig) Validate() error {
+	var errs configErrors
+	if c.Interval < 0 {
+		errs.addf("Interval must not be negative, got %v", c.Interval)
+	}
+	if c.TopN < 0 {
+		errs.addf("TopN must not be negative, got %d", c.TopN)
+	}
+	if c.MaxKeys < 0 {
+		errs.addf("MaxKeys must not be negative, got %d", c.MaxKeys)
+	}
+	return errs.err()
+}
+
+// notFoundAggregator đếm các hit 404 trong một chu kỳ
+type notFoundAggregator struct {
+	mu     sync.Mutex
+	config NotFoundAggregationConfig
+	total  uint64
+	paths  map[string]uint64
+	ips    map[string]uint64
+	since  time.Time
+
+	otherPaths uint64 // hit của path không được đếm riêng vì đã đủ MaxKeys
+	otherIPs   uint64 // hit của IP không được đếm riêng vì đã đủ MaxKeys
+}
+
+var (
+	notFoundMu  sync.RWMutex
+	notFoundAgg *notFoundAggregator
+)
+
+// StartNotFoundAggregation bật chế độ gộp log 404 cho các path không được
+// đăng ký (thường do scanner dò lỗ hổng). Thay vì mỗi hit một dòng log,
+// LogRequestMiddleware và LogResponseMiddleware bỏ qua các request này và
+// một dòng tổng hợp (top path/IP) được ghi theo chu kỳ. Metrics vẫn được ghi nhận.
+//
+// Trả về hàm stop để tắt chế độ gộp và ghi nốt log tổng hợp còn lại.
+func StartNotFoundAggregation(config NotFoundAggregationConfig) (stop func()) {
+	mustValidate("NotFoundAggregation", config)
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.TopN <= 0 {
+		config.TopN = 10
+	}
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = 10000
+	}
+
+	agg := &notFoundAggregator{config: config}
+	agg.reset()
+
+	notFoundMu.Lock()
+	notFoundAgg = agg
+	notFoundMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				agg.flush()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			notFoundMu.Lock()
+			if notFoundAgg == agg {
+				notFoundAgg = nil
+			}
+			notFoundMu.Unlock()
+			agg.flush()
+		})
+	}
+}
+
+// currentNotFoundAggregator trả về aggregator đang bật, nil nếu tắt
+func currentNotFoundAggregator() *notFoundAggregator {
+	notFoundMu.RLock()
+	defer notFoundMu.RUnlock()
+	return notFoundAgg
+}
+
+// isUnmatchedRoute kiểm tra request không khớp với route nào đã đăng ký
+func isUnmatchedRoute(c *gin.Context) bool {
+	return c.FullPath() == ""
+}
+
+// record ghi nhận một hit 404
+func (a *notFoundAggregator) record(path, ip string) {
+	a.mu.Lock()
+	a.total++
+	if !countCapped(a.paths, path, a.config.MaxKeys) {
+		a.otherPaths++
+	}
+	if !countCapped(a.ips, ip, a.config.MaxKeys) {
+		a.otherIPs++
+	}
+	a.mu.Unlock()
+}
+
+// countCapped tăng bộ đếm của key, trả về false nếu key mới mà counts đã có max key
+func countCapped(counts map[string]uint64, key string, max int) bool {
+	if _, ok := counts[key]; !ok && len(counts) >= max {
+		return false
+	}
+	counts[key]++
+	return true
+}
+
+// reset xoá bộ đếm cho chu kỳ mới, caller phải giữ lock (hoặc chưa chia sẻ)
+func (a *notFoundAggregator) reset() {
+	a.total = 0
+	a.paths = make(map[string]uint64)
+	a.ips = make(map[string]uint64)
+	a.since = time.Now()
+	a.otherPaths, a.otherIPs = 0, 0
+}
+
+// flush ghi log tổng hợp của chu kỳ hiện tại và reset bộ đếm
+func (a *notFoundAggregator) flush() {
+	a.mu.Lock()
+	total, paths, ips, since := a.total, a.paths, a.ips, a.since
+	otherPaths, otherIPs := a.otherPaths, a.otherIPs
+	a.reset()
+	a.mu.Unlock()
+
+	if total == 0 {
+		return
+	}
+	logMessage("[404 SUMMARY] %d hits to unregistered paths in %v\nTop paths: %s%s\nTop IPs: %s%s",
+		total, time.Since(since).Round(time.Second),
+		topCounts(paths, a.config.TopN), overflowNote(otherPaths, "paths"),
+		topCounts(ips, a.config.TopN), overflowNote(otherIPs, "IPs"))
+}
+
+// overflowNote mô tả số hit không được đếm riêng vì đã đủ MaxKeys, rỗng nếu không có
+func overflowNote(hits uint64, what string) string {
+	if hits == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (+%d hits to other %s not tracked, MaxKeys reached)", hits, what)
+}
+
+// topCounts trả về n phần tử có số đếm lớn nhất dưới dạng "key=count, ..."
+func topCounts(counts map[string]uint64, n int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, counts[k]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,363 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpsConfig cấu hình cho RegisterOpsEndpoints
+type OpsConfig struct {
+	// Auth bảo vệ các endpoint nhạy cảm (metrics, version, warm-up, openapi, ...).
+	// nil là từ chối (403) mọi endpoint nhạy cảm; để mở công khai, truyền một
+	// handler chỉ gọi c.Next(). Health và readiness luôn được mở cho orchestrator.
+	Auth gin.HandlerFunc
+
+	HealthPath  string // Mặc định "/healthz"
+	ReadyPath   string // Mặc định "/readyz"
+	MetricsPath string // Mặc định "/metrics"
+	WarmupPath  string // Mặc định "/warmup"
+	VersionPath string // Mặc định "/version"
+
+	// Engine bật endpoint báo cáo route coverage (route nào đã/chưa nhận traffic)
+	// và endpoint self-test của middleware stack. Số request được đọc từ Metrics
+	// của Core gắn với engine phục vụ endpoint vận hành, nên engine và endpoint
+	// vận hành phải dùng chung Core (hoặc cùng không gắn Core).
+	Engine       *gin.Engine
+	RoutesPath   string // Mặc định "/routes/coverage"
+	SelfTestPath string // Mặc định "/selftest"
+
+	// Schedules bật endpoint xem trạng thái các ScheduleRule
+	Schedules     bool
+	SchedulesPath string // Mặc định "/schedules"
+
+	// Allocations bật endpoint xem ước lượng cấp phát bộ nhớ theo route
+	// (cần AllocProfilingMiddleware)
+	Allocations     bool
+	AllocationsPath string // Mặc định "/debug/allocations"
+
+	// Goroutines bật endpoint xem snapshot stack goroutine do StartGoroutineWatchdog
+	// lưu khi phát hiện rò rỉ; ?live=1 lấy snapshot hiện tại
+	Goroutines     bool
+	GoroutinesPath string // Mặc định "/debug/goroutines"
+
+	// OpenAPI bật endpoint trả về OpenAPI fragment mô tả các endpoint vận hành
+	// đã được mount cùng error envelope chuẩn
+	OpenAPI     bool
+	OpenAPIPath string // Mặc định "/openapi.json"
+}
+
+// opsEndpoint mô tả một endpoint vận hành, dùng để sinh OpenAPI fragment
+type opsEndpoint struct {
+	Method    string
+	Path      string
+	Summary   string
+	Protected bool
+	Denied    bool                   // Protected nhưng OpsConfig.Auth là nil, luôn trả về 403
+	Schema    map[string]interface{} // Schema của response 200
+}
+
+var (
+	opsMu        sync.RWMutex
+	opsEndpoints = make(map[string]opsEndpoint)
+	notReady     atomic.Bool
+)
+
+// SetReady đánh dấu service sẵn sàng (hoặc không) nhận traffic,
+// readiness endpoint trả về 503 khi ready = false
+func SetReady(ready bool) {
+	notReady.Store(!ready)
+}
+
+// RegisterOpsEndpoints mount các endpoint vận hành (health, readiness, metrics)
+// lên router và tuỳ chọn endpoint OpenAPI mô tả chúng.
+func RegisterOpsEndpoints(r gin.IRouter, config OpsConfig) {
+	if config.HealthPath == "" {
+		config.HealthPath = "/healthz"
+	}
+	if config.ReadyPath == "" {
+		config.ReadyPath = "/readyz"
+	}
+	if config.MetricsPath == "" {
+		config.MetricsPath = "/metrics"
+	}
+	if config.WarmupPath == "" {
+		config.WarmupPath = "/warmup"
+	}
+	if config.VersionPath == "" {
+		config.VersionPath = "/version"
+	}
+	if config.SelfTestPath == "" {
+		config.SelfTestPath = "/selftest"
+	}
+	if config.RoutesPath == "" {
+		config.RoutesPath = "/routes/coverage"
+	}
+	if config.SchedulesPath == "" {
+		config.SchedulesPath = "/schedules"
+	}
+	if config.AllocationsPath == "" {
+		config.AllocationsPath = "/debug/allocations"
+	}
+	if config.GoroutinesPath == "" {
+		config.GoroutinesPath = "/debug/goroutines"
+	}
+	if config.OpenAPIPath == "" {
+		config.OpenAPIPath = "/openapi.json"
+	}
+
+	mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.HealthPath,
+		Summary: "Liveness probe", Schema: statusSchema()}, false, healthHandler)
+	mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.ReadyPath,
+		Summary: "Readiness probe", Schema: readinessSchema()}, false, readyHandler)
+	mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.WarmupPath,
+		Summary: "Warm-up status, starts warm-up on first call", Schema: map[string]interface{}{"type": "object"}},
+		true, warmupHandler)
+	mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.MetricsPath,
+		Summary: "Request metrics snapshot", Schema: map[string]interface{}{"type": "object"}}, true, metricsHandler)
+
+	mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.VersionPath,
+		Summary: "Build and version information", Schema: map[string]interface{}{"type": "object"}},
+		true, func(c *gin.Context) {
+			c.JSON(http.StatusOK, ReadBuildInfo())
+		})
+
+	if config.Engine != nil {
+		engine := config.Engine
+		mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.RoutesPath,
+			Summary: "Traffic coverage of registered routes", Schema: map[string]interface{}{"type": "array"}},
+			true, func(c *gin.Context) {
+				c.JSON(http.StatusOK, metricsOf(c).RouteCoverage(engine))
+			})
+		mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.SelfTestPath,
+			Summary: "Run a synthetic request through the middleware stack", Schema: map[string]interface{}{"type": "object"}},
+			true, func(c *gin.Context) {
+				c.JSON(http.StatusOK, RunSelfTest(engine))
+			})
+	}
+
+	if config.Schedules {
+		mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.SchedulesPath,
+			Summary: "State of schedule-based access rules", Schema: map[string]interface{}{"type": "array"}},
+			true, func(c *gin.Context) {
+				c.JSON(http.StatusOK, ScheduleStates())
+			})
+	}
+
+	if config.Allocations {
+		mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.AllocationsPath,
+			Summary: "Per-route heap allocation estimates", Schema: map[string]interface{}{"type": "array"}},
+			true, func(c *gin.Context) {
+				c.JSON(http.StatusOK, AllocationProfile())
+			})
+	}
+
+	if config.Goroutines {
+		mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.GoroutinesPath,
+			Summary: "Goroutine stacks saved on the last suspected leak", Schema: map[string]interface{}{"type": "string"}},
+			true, goroutinesHandler)
+	}
+
+	if config.OpenAPI {
+		mountOps(r, config, opsEndpoint{Method: http.MethodGet, Path: config.OpenAPIPath,
+			Summary: "OpenAPI fragment of operational endpoints", Schema: map[string]interface{}{"type": "object"}},
+			true, func(c *gin.Context) {
+				c.JSON(http.StatusOK, OpenAPIFragment())
+			})
+	}
+}
+
+// mountOps đăng ký handler lên router và ghi nhận endpoint cho OpenAPI;
+// endpoint nhạy cảm bị từ chối khi không có Auth
+func mountOps(r gin.IRouter, config OpsConfig, endpoint opsEndpoint, protected bool, handler gin.HandlerFunc) {
+	handlers := []gin.HandlerFunc{handler}
+	if protected {
+		auth := config.Auth
+		if auth == nil {
+			auth = denyOpsHandler
+			endpoint.Denied = true
+		}
+		handlers = []gin.HandlerFunc{auth, handler}
+		endpoint.Protected = true
+	}
+	r.Handle(endpoint.Method, endpoint.Path, handlers...)
+
+	if group, ok := r.(interface{ BasePath() string }); ok {
+		endpoint.Path = joinPaths(group.BasePath(), endpoint.Path)
+	}
+	opsMu.Lock()
+	opsEndpoints[endpoint.Method+" "+endpoint.Path] = endpoint
+	opsMu.Unlock()
+}
+
+// denyOpsHandler từ chối endpoint nhạy cảm khi OpsConfig.Auth chưa được cấu hình
+func denyOpsHandler(c *gin.Context) {
+	AbortWithReason(c, http.StatusForbidden, "ops", "OpsConfig.Auth is not configured", gin.H{
+		"message": Message(c, MessageForbidden),
+	})
+}
+
+// healthHandler trả về trạng thái liveness
+func healthHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyHandler trả về trạng thái readiness, tổng hợp từ SetReady, warm-up,
+// các dependency check và circuit breaker đã đăng ký. Warm-up chưa chạy được
+// khởi chạy ở lần gọi đầu tiên (xem RegisterWarmup).
+func readyHandler(c *gin.Context) {
+	startWarmups(context.WithoutCancel(c.Request.Context()))
+	report := CheckReadiness(c.Request.Context())
+	status, code := "ready", http.StatusOK
+	if !report.Ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":        status,
+		"warmup":        WarmupStatus(),
+		"dependencies":  report.Dependencies,
+		"open_breakers": report.OpenBreakers,
+	})
+}
+
+// warmupHandler khởi chạy warm-up (nếu chưa chạy) và trả về 200 khi đã hoàn tất,
+// 503 khi đang chạy. Warm-up tiếp tục chạy sau khi request kết thúc.
+func warmupHandler(c *gin.Context) {
+	startWarmups(context.WithoutCancel(c.Request.Context()))
+	report := WarmupStatus()
+	code := http.StatusOK
+	if !report.Complete {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
+
+// metricsHandler trả về snapshot metrics và thống kê logger của Core gắn với request
+func metricsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, metricsOf(c).snapshot(loggerOf(c)))
+}
+
+// OpenAPIFragment trả về OpenAPI 3 fragment mô tả các endpoint vận hành
+// đã được mount và error envelope chuẩn của package
+func OpenAPIFragment() map[string]interface{} {
+	opsMu.RLock()
+	endpoints := make([]opsEndpoint, 0, len(opsEndpoints))
+	for _, e := range opsEndpoints {
+		endpoints = append(endpoints, e)
+	}
+	opsMu.RUnlock()
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Path < endpoints[j].Path })
+
+	paths := make(map[string]interface{}, len(endpoints))
+	for _, e := range endpoints {
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content":     jsonContent(e.Schema),
+			},
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content":     jsonContent(map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}),
+			},
+		}
+		operation := map[string]interface{}{
+			"summary":   e.Summary,
+			"tags":      []string{"operations"},
+			"responses": responses,
+		}
+		switch {
+		case e.Denied:
+			responses["403"] = map[string]interface{}{"description": "Forbidden: OpsConfig.Auth is not configured"}
+		case e.Protected:
+			// Auth do người dùng cung cấp có thể trả về 401 (thiếu credential) hoặc 403
+			responses["401"] = map[string]interface{}{"description": "Unauthorized"}
+			responses["403"] = map[string]interface{}{"description": "Forbidden"}
+		}
+
+		item, _ := paths[e.Path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[e.Path] = item
+		}
+		item[strings.ToLower(e.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Operational endpoints",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"ErrorResponse": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"message"},
+					"properties": map[string]interface{}{
+						"message":    map[string]interface{}{"type": "string"},
+						"request_id": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// statusSchema là schema của response {"status": "..."}
+func statusSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"status": map[string]interface{}{"type": "string"},
+		},
+	}
+}
+
+// readinessSchema là schema của response readiness
+func readinessSchema() map[string]interface{} {
+	schema := statusSchema()
+	properties := schema["properties"].(map[string]interface{})
+	properties["warmup"] = map[string]interface{}{"type": "object"}
+	properties["dependencies"] = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}}
+	properties["open_breakers"] = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+	return schema
+}
+
+// jsonContent bọc schema trong content type application/json
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// joinPaths nối base path của router group với path tương đối
+func joinPaths(base, path string) string {
+	if base == "" || base == "/" {
+		return path
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// goroutinesHandler trả về snapshot stack goroutine dạng text
+func goroutinesHandler(c *gin.Context) {
+	if c.Query("live") == "1" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", captureGoroutineStacks())
+		return
+	}
+	snapshot, report := GoroutineSnapshot()
+	if report == nil {
+		c.String(http.StatusNotFound, "no goroutine leak detected yet, use ?live=1 for the current stacks\n")
+		return
+	}
+	header := fmt.Sprintf("# leak suspected at %s: %d goroutines, %d in-flight requests, +%d over %d samples\n\n",
+		report.Time.Format(time.RFC3339), report.Goroutines, report.InFlight, report.Growth, report.Window)
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", append([]byte(header), snapshot...))
+}
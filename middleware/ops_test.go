@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// opsResponses trả về các response code OpenAPI của GET /metrics
+func opsResponses(t *testing.T) map[string]interface{} {
+	t.Helper()
+	paths := OpenAPIFragment()["paths"].(map[string]interface{})
+	operation := paths["/metrics"].(map[string]interface{})["get"].(map[string]interface{})
+	return operation["responses"].(map[string]interface{})
+}
+
+func TestOpsWithoutAuthDeniesAndDocuments403(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterOpsEndpoints(r, OpsConfig{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("got %d, want 403", w.Code)
+	}
+	responses := opsResponses(t)
+	if _, ok := responses["403"]; !ok {
+		t.Fatalf("responses: got %v, want 403 documented", responses)
+	}
+	if _, ok := responses["401"]; ok {
+		t.Fatalf("responses: got %v, want no 401 when Auth is not configured", responses)
+	}
+}
+
+func TestOpsWithAuthDocuments401And403(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterOpsEndpoints(r, OpsConfig{Auth: func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("got %d, want 401 from Auth", w.Code)
+	}
+	responses := opsResponses(t)
+	for _, code := range []string{"401", "403"} {
+		if _, ok := responses[code]; !ok {
+			t.Fatalf("responses: got %v, want %s documented", responses, code)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Các wrapper của package phải implement http.Pusher để không vô tình tắt HTTP/2 push
+var (
+	_ http.Pusher = (*ResponseWriter)(nil)
+	_ http.Pusher = (*bufferedWriter)(nil)
+	_ http.Pusher = (*lastModifiedWriter)(nil)
+	_ http.Pusher = (*headerFinalizer)(nil)
+)
+
+// Push implements http.Pusher, chuyển tiếp tới Pusher của writer gốc
+func (w *ResponseWriter) Push(target string, opts *http.PushOptions) error {
+	return pushVia(w.ResponseWriter, target, opts)
+}
+
+// Push implements http.Pusher, chuyển tiếp tới Pusher của writer gốc
+func (w *bufferedWriter) Push(target string, opts *http.PushOptions) error {
+	return pushVia(w.ResponseWriter, target, opts)
+}
+
+// Push implements http.Pusher, chuyển tiếp tới Pusher của writer gốc
+func (w *lastModifiedWriter) Push(target string, opts *http.PushOptions) error {
+	return pushVia(w.ResponseWriter, target, opts)
+}
+
+// Push implements http.Pusher, chuyển tiếp tới Pusher của writer gốc
+func (w *headerFinalizer) Push(target string, opts *http.PushOptions) error {
+	return pushVia(w.ResponseWriter, target, opts)
+}
+
+// pushVia push target qua Pusher của writer, trả về http.ErrNotSupported
+// nếu kết nối không hỗ trợ (HTTP/1.x)
+func pushVia(w gin.ResponseWriter, target string, opts *http.PushOptions) error {
+	if pusher := w.Pusher(); pusher != nil {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// PushAssetsMiddleware trả về middleware khai báo các asset cần push (HTTP/2
+// server push) cho route, ví dụ:
+//
+//	r.GET("/", middleware.PushAssetsMiddleware("/static/app.css", "/static/app.js"), indexHandler)
+//
+// Với kết nối không hỗ trợ push, middleware không làm gì.
+func PushAssetsMiddleware(assets ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Writer.Pusher() != nil {
+			for _, asset := range assets {
+				err := pushVia(c.Writer, asset, nil)
+				if errors.Is(err, http.ErrNotSupported) {
+					break
+				}
+				if err != nil {
+					loggerOf(c).LogError(RequestID(c), fmt.Errorf("push %s: %w", asset, err))
+				}
+			}
+		}
+		c.Next()
+	}
+}
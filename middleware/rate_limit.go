@@ -0,0 +1,260 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitResult là kết quả quyết định của một RateLimiter cho một request
+type RateLimitResult struct {
+	Allowed   bool          // Request có được phép hay không
+	Limit     int           // Số request tối đa trong một cửa sổ
+	Remaining int           // Số request còn lại trong cửa sổ hiện tại
+	Reset     time.Duration // Thời gian tới khi quota được khôi phục
+}
+
+// RateLimiter quyết định một request (theo key) có được phép hay không
+type RateLimiter interface {
+	Allow(key string) RateLimitResult
+}
+
+// requestRateLimiter được implement bởi RateLimiter cần request hiện tại (context
+// để huỷ lệnh store, logger của Core để log lỗi); middleware của package ưu tiên
+// allowRequest khi có
+type requestRateLimiter interface {
+	allowRequest(c *gin.Context, key string) RateLimitResult
+}
+
+// allowRateLimit gọi limiter cho request c
+func allowRateLimit(c *gin.Context, l RateLimiter, key string) RateLimitResult {
+	if rl, ok := l.(requestRateLimiter); ok {
+		return rl.allowRequest(c, key)
+	}
+	return l.Allow(key)
+}
+
+// RateLimitHeaderMode chọn bộ header rate limit được gửi về client
+type RateLimitHeaderMode int
+
+const (
+	// RateLimitHeadersBoth gửi cả header chuẩn (draft IETF) và header X-RateLimit-*
+	RateLimitHeadersBoth RateLimitHeaderMode = iota
+	// RateLimitHeadersStandard chỉ gửi RateLimit-Limit/RateLimit-Remaining/RateLimit-Reset
+	RateLimitHeadersStandard
+	// RateLimitHeadersLegacy chỉ gửi X-RateLimit-Limit/X-RateLimit-Remaining/X-RateLimit-Reset
+	RateLimitHeadersLegacy
+	// RateLimitHeadersNone không gửi header nào
+	RateLimitHeadersNone
+)
+
+// RateLimitConfig cấu hình cho RateLimitMiddleware
+type RateLimitConfig struct {
+	Limiter RateLimiter                 // Bộ giới hạn, bắt buộc
+	KeyFunc func(c *gin.Context) string // Hàm lấy key, mặc định là ClientIP
+	Headers RateLimitHeaderMode         // Mặc định RateLimitHeadersBoth
+}
+
+// Validate kiểm tra tính hợp lệ của RateLimitConfig
+func (c RateLimitConfig) Validate() error {
+	var errs configErrors
+	if c.Limiter == nil {
+		errs.addf("Limiter is required")
+	}
+	errs.checkHeaderMode(c.Headers)
+	return errs.err()
+}
+
+// checkHeaderMode kiểm tra RateLimitHeaderMode nằm trong các giá trị đã định nghĩa
+func (e *configErrors) checkHeaderMode(mode RateLimitHeaderMode) {
+	if mode < RateLimitHeadersBoth || mode > RateLimitHeadersNone {
+		e.addf("Headers: unknown header mode %d", mode)
+	}
+}
+
+// RateLimitMiddleware trả về middleware giới hạn số request theo key.
+// Với mọi limiter, header rate limit chuẩn được gửi kèm response; khi vượt
+// giới hạn, trả về 429 cùng header Retry-After.
+func RateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
+	mustValidate("RateLimit", config)
+	return CompositeRateLimitMiddleware(CompositeRateLimitConfig{
+		Rules:   []RateLimitRule{{Limiter: config.Limiter, KeyFunc: config.KeyFunc}},
+		Headers: config.Headers,
+	})
+}
+
+// setRateLimitHeaders ghi header rate limit theo mode đã cấu hình
+func setRateLimitHeaders(c *gin.Context, mode RateLimitHeaderMode, result RateLimitResult) {
+	limit := strconv.Itoa(result.Limit)
+	remaining := strconv.Itoa(max(result.Remaining, 0))
+	reset := ceilSeconds(result.Reset)
+
+	if mode == RateLimitHeadersBoth || mode == RateLimitHeadersStandard {
+		c.Header("RateLimit-Limit", limit)
+		c.Header("RateLimit-Remaining", remaining)
+		c.Header("RateLimit-Reset", strconv.Itoa(reset))
+	}
+	if mode == RateLimitHeadersBoth || mode == RateLimitHeadersLegacy {
+		c.Header("X-RateLimit-Limit", limit)
+		c.Header("X-RateLimit-Remaining", remaining)
+		// X-RateLimit-Reset theo thông lệ là unix timestamp
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.Reset).Unix(), 10))
+	}
+}
+
+// ceilSeconds làm tròn lên duration thành số giây
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+// BurstShape quyết định trạng thái ban đầu của một bucket mới
+type BurstShape int
+
+const (
+	// BurstFull: bucket mới bắt đầu đầy, hấp thụ ngay một đợt burst
+	BurstFull BurstShape = iota
+	// BurstSmooth: bucket mới chỉ có một token, traffic phải tăng dần theo Rate
+	BurstSmooth
+)
+
+// TokenBucketConfig cấu hình cho TokenBucketLimiter
+type TokenBucketConfig struct {
+	Rate  float64    // Số token được nạp mỗi giây khi đã warm-up xong
+	Burst int        // Dung lượng tối đa của bucket khi đã warm-up xong
+	Shape BurstShape // Trạng thái ban đầu của bucket mới, mặc định BurstFull
+
+	// WarmUp là khoảng thời gian (tính từ khi tạo limiter, thường là lúc deploy)
+	// mà Rate và Burst tăng tuyến tính từ WarmUpStartFactor lên 100%
+	WarmUp time.Duration
+	// WarmUpStartFactor là tỉ lệ (0, 1] của Rate/Burst tại thời điểm bắt đầu warm-up, mặc định 0.1
+	WarmUpStartFactor float64
+}
+
+// Validate kiểm tra tính hợp lệ của TokenBucketConfig
+func (c TokenBucketConfig) Validate() error {
+	var errs configErrors
+	if c.Rate <= 0 {
+		errs.addf("Rate must be positive, got %v", c.Rate)
+	}
+	if c.Burst <= 0 {
+		errs.addf("Burst must be positive, got %d", c.Burst)
+	}
+	if c.Shape != BurstFull && c.Shape != BurstSmooth {
+		errs.addf("Shape: unknown burst shape %d", c.Shape)
+	}
+	if c.WarmUp < 0 {
+		errs.addf("WarmUp must not be negative, got %v", c.WarmUp)
+	}
+	if c.WarmUpStartFactor < 0 || c.WarmUpStartFactor > 1 {
+		errs.addf("WarmUpStartFactor must be within (0, 1], got %v", c.WarmUpStartFactor)
+	}
+	return errs.err()
+}
+
+// tokenBucketSweepInterval là khoảng cách tối thiểu giữa hai lần dọn bucket đã đầy
+const tokenBucketSweepInterval = time.Minute
+
+// TokenBucketLimiter là RateLimiter dạng token bucket, mỗi key có một bucket riêng.
+// Bucket đã nạp đầy lại được dọn định kỳ vì không khác gì bucket mới, nên số
+// bucket chỉ tỉ lệ với số key còn hoạt động (với BurstSmooth, key nghỉ đủ lâu để
+// đầy lại được coi là key mới và bắt đầu lại từ một token).
+type TokenBucketLimiter struct {
+	mu        sync.Mutex
+	config    TokenBucketConfig
+	created   time.Time
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket lưu trạng thái của một bucket
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucketLimiter tạo TokenBucketLimiter với tốc độ nạp rate (token/giây)
+// và dung lượng burst
+func NewTokenBucketLimiter(rate float64, burst int) *TokenBucketLimiter {
+	return NewTokenBucketLimiterWithConfig(TokenBucketConfig{Rate: rate, Burst: burst})
+}
+
+// NewTokenBucketLimiterWithConfig tạo TokenBucketLimiter với warm-up và burst shape
+//
+// Panic nếu config không hợp lệ (xem TokenBucketConfig.Validate).
+func NewTokenBucketLimiterWithConfig(config TokenBucketConfig) *TokenBucketLimiter {
+	mustValidate("TokenBucket", config)
+	if config.WarmUpStartFactor <= 0 || config.WarmUpStartFactor > 1 {
+		config.WarmUpStartFactor = 0.1
+	}
+	return &TokenBucketLimiter{
+		config:  config,
+		created: time.Now(),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// capacity trả về rate và burst hiệu dụng tại thời điểm now (có tính warm-up)
+func (l *TokenBucketLimiter) capacity(now time.Time) (rate, burst float64) {
+	factor := 1.0
+	if l.config.WarmUp > 0 {
+		progress := math.Min(1, float64(now.Sub(l.created))/float64(l.config.WarmUp))
+		factor = l.config.WarmUpStartFactor + (1-l.config.WarmUpStartFactor)*progress
+	}
+	return l.config.Rate * factor, math.Max(1, math.Floor(float64(l.config.Burst)*factor))
+}
+
+// Allow implements RateLimiter
+func (l *TokenBucketLimiter) Allow(key string) RateLimitResult {
+	now := time.Now()
+	rate, burst := l.capacity(now)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= tokenBucketSweepInterval {
+		l.sweep(now, rate, burst)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		if l.config.Shape == BurstSmooth {
+			b.tokens = 1
+		}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := RateLimitResult{Limit: int(burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	}
+	result.Remaining = int(b.tokens)
+	if rate > 0 {
+		// Khi bị từ chối, Reset là thời gian tới khi có token tiếp theo;
+		// ngược lại là thời gian tới khi bucket đầy lại
+		missing := burst - b.tokens
+		if !result.Allowed {
+			missing = 1 - b.tokens
+		}
+		result.Reset = time.Duration(missing / rate * float64(time.Second))
+	}
+	return result
+}
+
+// sweep xoá các bucket đã nạp đầy lại tại thời điểm now, caller phải giữ lock
+func (l *TokenBucketLimiter) sweep(now time.Time, rate, burst float64) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*rate >= burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitRule là một limiter có tên cùng hàm lấy key riêng
+type RateLimitRule struct {
+	Name    string                      // Tên rule, hiển thị trong log (ví dụ "ip", "api-key", "global")
+	Limiter RateLimiter                 // Bộ giới hạn của rule
+	KeyFunc func(c *gin.Context) string // Hàm lấy key; trả về "" để bỏ qua rule cho request này
+}
+
+// CompositeRateLimitConfig cấu hình cho CompositeRateLimitMiddleware
+type CompositeRateLimitConfig struct {
+	Rules   []RateLimitRule     // Các rule, được đánh giá theo thứ tự
+	Headers RateLimitHeaderMode // Mặc định RateLimitHeadersBoth
+}
+
+// Validate kiểm tra tính hợp lệ của CompositeRateLimitConfig
+func (c CompositeRateLimitConfig) Validate() error {
+	var errs configErrors
+	if len(c.Rules) == 0 {
+		errs.addf("Rules: at least one rule is required")
+	}
+	names := make(map[string]bool, len(c.Rules))
+	for i, rule := range c.Rules {
+		if rule.Limiter == nil {
+			errs.addf("Rules[%d] (%q): Limiter is required", i, rule.Name)
+		}
+		if names[rule.Name] {
+			errs.addf("Rules[%d]: name %q is used by another rule, decisions in logs would be ambiguous", i, rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	errs.checkHeaderMode(c.Headers)
+	return errs.err()
+}
+
+// CompositeRateLimitMiddleware trả về middleware kết hợp nhiều limiter
+// (ví dụ theo IP, theo API key và toàn cục) trong một lần xử lý.
+// Các rule được đánh giá theo thứ tự và dừng ngay ở rule đầu tiên từ chối;
+// header rate limit phản ánh rule hạn chế nhất. Quyết định tổng hợp được lưu
+// trong context và xuất hiện trong log response (LogEntry.RateLimit).
+func CompositeRateLimitMiddleware(config CompositeRateLimitConfig) gin.HandlerFunc {
+	mustValidate("CompositeRateLimit", config)
+	return func(c *gin.Context) {
+		var (
+			decisions  []string
+			reported   RateLimitResult
+			haveReport bool
+		)
+
+		for _, rule := range config.Rules {
+			key := c.ClientIP()
+			if rule.KeyFunc != nil {
+				key = rule.KeyFunc(c)
+			}
+			if key == "" {
+				continue
+			}
+
+			result := allowRateLimit(c, rule.Limiter, key)
+			decisions = append(decisions, formatRateLimitDecision(rule.Name, result))
+			if !haveReport || !result.Allowed || result.Remaining < reported.Remaining {
+				reported, haveReport = result, true
+			}
+			if !result.Allowed {
+				break
+			}
+		}
+
+		if !haveReport {
+			c.Next()
+			return
+		}
+
+		c.Set(ContextKeyRateLimitDecision, strings.Join(decisions, ","))
+		setRateLimitHeaders(c, config.Headers, reported)
+
+		if !reported.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(reported.Reset)))
+			AbortWithReason(c, 429, "rate_limit", "limit exceeded: "+decisions[len(decisions)-1], gin.H{
+				"message": Message(c, MessageTooManyRequests),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// formatRateLimitDecision định dạng kết quả một rule thành "name=allow(remaining/limit)"
+func formatRateLimitDecision(name string, result RateLimitResult) string {
+	verdict := "allow"
+	if !result.Allowed {
+		verdict = "deny"
+	}
+	if name == "" {
+		name = "default"
+	}
+	return fmt.Sprintf("%s=%s(%d/%d)", name, verdict, max(result.Remaining, 0), result.Limit)
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kimxuanhong/go-middleware/store"
+)
+
+// StoreRateLimiter là RateLimiter dạng fixed window dùng store.Store,
+// cho phép chia sẻ quota giữa nhiều instance khi dùng store phân tán (Redis, ...)
+type StoreRateLimiter struct {
+	store  store.Store
+	limit  int
+	window time.Duration
+	prefix string
+}
+
+// NewStoreRateLimiter tạo StoreRateLimiter cho phép tối đa limit request mỗi window
+//
+// Panic nếu s là nil hoặc limit, window không dương.
+func NewStoreRateLimiter(s store.Store, limit int, window time.Duration) *StoreRateLimiter {
+	mustValidate("StoreRateLimiter", validatorFunc(func() error {
+		var errs configErrors
+		if s == nil {
+			errs.addf("Store is required")
+		}
+		if limit <= 0 {
+			errs.addf("limit must be positive, got %d", limit)
+		}
+		if window <= 0 {
+			errs.addf("window must be positive, got %v", window)
+		}
+		return errs.err()
+	}))
+	return &StoreRateLimiter{store: s, limit: limit, window: window, prefix: "ratelimit:"}
+}
+
+// Allow implements RateLimiter. Khi store lỗi, request được cho qua (fail open)
+// và lỗi được log.
+func (l *StoreRateLimiter) Allow(key string) RateLimitResult {
+	return l.allow(context.Background(), defaultLogger, "", key)
+}
+
+// allowRequest implements requestRateLimiter: dùng context của request cho store
+// và log lỗi qua logger của Core kèm request ID
+func (l *StoreRateLimiter) allowRequest(c *gin.Context, key string) RateLimitResult {
+	return l.allow(c.Request.Context(), loggerOf(c), RequestID(c), key)
+}
+
+// allow tăng bộ đếm của window hiện tại trong store
+func (l *StoreRateLimiter) allow(ctx context.Context, logger Logger, requestID, key string) RateLimitResult {
+	now := time.Now()
+	windowStart := now.Truncate(l.window)
+	reset := windowStart.Add(l.window).Sub(now)
+	storeKey := l.prefix + key + ":" + strconv.FormatInt(windowStart.Unix(), 10)
+
+	count, err := l.store.Incr(ctx, storeKey, 1, l.window)
+	if err != nil {
+		logger.LogError(requestID, fmt.Errorf("rate limit store: %w", err))
+		return RateLimitResult{Allowed: true, Limit: l.limit, Remaining: l.limit, Reset: reset}
+	}
+
+	return RateLimitResult{
+		Allowed:   count <= int64(l.limit),
+		Limit:     l.limit,
+		Remaining: l.limit - int(count),
+		Reset:     reset,
+	}
+}
+
+// NewRedisRateLimiter tạo StoreRateLimiter dùng Redis qua store.RedisClient,
+// quota được chia sẻ giữa mọi instance dùng chung Redis
+//
+// Panic nếu client là nil hoặc limit, window không dương.
+func NewRedisRateLimiter(client store.RedisClient, limit int, window time.Duration) *StoreRateLimiter {
+	mustValidate("RedisRateLimiter", validatorFunc(func() error {
+		if client == nil {
+			return errors.New("RedisClient is required")
+		}
+		return nil
+	}))
+	return NewStoreRateLimiter(store.NewRedisStore(client, ""), limit, window)
+}
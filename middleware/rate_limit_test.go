@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTokenBucketLimiterBurstAndPerKey(t *testing.T) {
+	// Rate rất nhỏ để bucket không kịp nạp lại trong lúc test chạy
+	l := NewTokenBucketLimiter(0.001, 3)
+
+	for i := 0; i < 3; i++ {
+		if r := l.Allow("a"); !r.Allowed || r.Remaining != 2-i || r.Limit != 3 {
+			t.Fatalf("request %d: got %+v, want allowed with %d remaining", i, r, 2-i)
+		}
+	}
+	r := l.Allow("a")
+	if r.Allowed || r.Remaining != 0 {
+		t.Fatalf("over burst: got %+v, want rejected", r)
+	}
+	if r.Reset <= 0 || r.Reset > time.Duration(1/0.001*float64(time.Second)) {
+		t.Fatalf("reset: got %v, want time until the next token", r.Reset)
+	}
+	if r := l.Allow("b"); !r.Allowed {
+		t.Fatalf("other key: got %+v, want allowed", r)
+	}
+}
+
+func TestTokenBucketLimiterRefills(t *testing.T) {
+	l := NewTokenBucketLimiter(0.001, 1)
+	if !l.Allow("a").Allowed {
+		t.Fatal("first request: want allowed")
+	}
+	if l.Allow("a").Allowed {
+		t.Fatal("second request: want rejected")
+	}
+
+	// Lùi thời điểm nạp cuối để mô phỏng 1000s trôi qua (đủ một token)
+	l.mu.Lock()
+	l.buckets["a"].last = l.buckets["a"].last.Add(-1000 * time.Second)
+	l.mu.Unlock()
+	if !l.Allow("a").Allowed {
+		t.Fatal("after refill: want allowed")
+	}
+}
+
+func TestTokenBucketLimiterSweepsRefilledBuckets(t *testing.T) {
+	l := NewTokenBucketLimiter(1, 2)
+	l.Allow("idle")
+	l.Allow("busy")
+	l.Allow("busy")
+
+	l.mu.Lock()
+	now := time.Now()
+	l.buckets["idle"].last = now.Add(-time.Second) // Đã nạp đầy lại
+	l.lastSweep = now.Add(-tokenBucketSweepInterval)
+	l.mu.Unlock()
+
+	l.Allow("other")
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.buckets["idle"]; ok {
+		t.Fatal("idle: want refilled bucket swept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Fatal("busy: want bucket kept while not refilled")
+	}
+}
+
+func TestRateLimitMiddlewareHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(mode RateLimitHeaderMode, limiter RateLimiter) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(RateLimitMiddleware(RateLimitConfig{Limiter: limiter, Headers: mode}))
+		r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	tests := []struct {
+		mode             RateLimitHeaderMode
+		standard, legacy bool
+	}{
+		{RateLimitHeadersBoth, true, true},
+		{RateLimitHeadersStandard, true, false},
+		{RateLimitHeadersLegacy, false, true},
+		{RateLimitHeadersNone, false, false},
+	}
+	for _, tt := range tests {
+		w := serve(tt.mode, NewTokenBucketLimiter(1, 5))
+		if got := w.Header().Get("RateLimit-Limit") == "5"; got != tt.standard {
+			t.Errorf("mode %d: RateLimit-Limit = %q, want present %v", tt.mode, w.Header().Get("RateLimit-Limit"), tt.standard)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit") == "5"; got != tt.legacy {
+			t.Errorf("mode %d: X-RateLimit-Limit = %q, want present %v", tt.mode, w.Header().Get("X-RateLimit-Limit"), tt.legacy)
+		}
+	}
+
+	l := NewTokenBucketLimiter(0.5, 1)
+	serve(RateLimitHeadersStandard, l)
+	w := serve(RateLimitHeadersStandard, l)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("over limit: got %d, want 429", w.Code)
+	}
+	if w.Header().Get("RateLimit-Remaining") != "0" || w.Header().Get("Retry-After") != "2" {
+		t.Fatalf("over limit headers: got %v, want remaining 0 and Retry-After 2", w.Header())
+	}
+}
+
+func TestTokenBucketLimiterSmoothShape(t *testing.T) {
+	l := NewTokenBucketLimiterWithConfig(TokenBucketConfig{Rate: 0.001, Burst: 5, Shape: BurstSmooth})
+	if !l.Allow("a").Allowed {
+		t.Fatal("first request: want allowed")
+	}
+	if l.Allow("a").Allowed {
+		t.Fatal("second request: want rejected, a smooth bucket starts with one token")
+	}
+}
+
+func TestTokenBucketLimiterWarmUp(t *testing.T) {
+	l := NewTokenBucketLimiterWithConfig(TokenBucketConfig{Rate: 10, Burst: 100, WarmUp: time.Hour, WarmUpStartFactor: 0.1})
+	if _, burst := l.capacity(l.created); burst != 10 {
+		t.Fatalf("burst at start: got %v, want 10", burst)
+	}
+	if rate, burst := l.capacity(l.created.Add(2 * time.Hour)); rate != 10 || burst != 100 {
+		t.Fatalf("capacity after warm-up: got %v, %v, want 10, 100", rate, burst)
+	}
+}
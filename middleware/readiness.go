@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DependencyCheck là một kiểm tra tình trạng dependency (database, cache, upstream, ...)
+type DependencyCheck struct {
+	Name  string                          // Tên dependency
+	Check func(ctx context.Context) error // Trả về lỗi nếu dependency không khoẻ
+	// FailureThreshold là số lần lỗi liên tiếp để readiness chuyển sang not ready, mặc định 3
+	FailureThreshold int
+	Timeout          time.Duration // Timeout mỗi lần kiểm tra, mặc định 2 giây
+}
+
+// Validate kiểm tra tính hợp lệ của DependencyCheck
+func (d DependencyCheck) Validate() error {
+	var errs configErrors
+	if d.Name == "" {
+		errs.addf("Name is required")
+	}
+	if d.Check == nil {
+		errs.addf("Check is required")
+	}
+	if d.FailureThreshold < 0 {
+		errs.addf("FailureThreshold must not be negative, got %d", d.FailureThreshold)
+	}
+	if d.Timeout < 0 {
+		errs.addf("Timeout must not be negative, got %v", d.Timeout)
+	}
+	return errs.err()
+}
+
+// BreakerState là trạng thái của một circuit breaker tới upstream quan trọng,
+// dùng cho readiness (RegisterCriticalBreaker) và CircuitBreakerMiddleware
+type BreakerState interface {
+	IsOpen() bool
+}
+
+// DependencyStatus là kết quả kiểm tra một dependency
+type DependencyStatus struct {
+	Name                string `json:"name"`
+	Healthy             bool   `json:"healthy"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Error               string `json:"error,omitempty"`
+}
+
+// ReadinessReport là kết quả tổng hợp của readiness probe
+type ReadinessReport struct {
+	Ready        bool               `json:"ready"`
+	Warm         bool               `json:"warm"`
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+	OpenBreakers []string           `json:"open_breakers,omitempty"`
+}
+
+// dependencyState lưu số lần lỗi liên tiếp của một DependencyCheck
+type dependencyState struct {
+	mu       sync.Mutex
+	check    DependencyCheck
+	failures int
+	lastErr  string
+}
+
+var (
+	readinessMu  sync.RWMutex
+	dependencies = make(map[string]*dependencyState)
+	breakers     = make(map[string]BreakerState)
+)
+
+// RegisterDependencyCheck đăng ký kiểm tra dependency cho readiness endpoint.
+// Readiness chuyển sang not ready khi kiểm tra lỗi FailureThreshold lần liên tiếp
+// và trở lại ready ngay khi kiểm tra thành công.
+//
+// Panic nếu check không hợp lệ (xem DependencyCheck.Validate).
+func RegisterDependencyCheck(check DependencyCheck) {
+	mustValidate("DependencyCheck", check)
+	if check.FailureThreshold == 0 {
+		check.FailureThreshold = 3
+	}
+	if check.Timeout == 0 {
+		check.Timeout = 2 * time.Second
+	}
+	readinessMu.Lock()
+	dependencies[check.Name] = &dependencyState{check: check}
+	readinessMu.Unlock()
+}
+
+// RegisterCriticalBreaker đăng ký circuit breaker tới một upstream quan trọng;
+// readiness là not ready khi breaker đang mở
+func RegisterCriticalBreaker(name string, breaker BreakerState) {
+	readinessMu.Lock()
+	breakers[name] = breaker
+	readinessMu.Unlock()
+}
+
+// CheckReadiness chạy song song mọi kiểm tra dependency và trả về báo cáo readiness
+func CheckReadiness(ctx context.Context) ReadinessReport {
+	readinessMu.RLock()
+	states := make([]*dependencyState, 0, len(dependencies))
+	for _, s := range dependencies {
+		states = append(states, s)
+	}
+	openBreakers := []string{}
+	for name, b := range breakers {
+		if b.IsOpen() {
+			openBreakers = append(openBreakers, name)
+		}
+	}
+	readinessMu.RUnlock()
+
+	warm := WarmupStatus().Complete
+	report := ReadinessReport{
+		Ready:        !notReady.Load() && len(openBreakers) == 0 && warm,
+		Warm:         warm,
+		Dependencies: make([]DependencyStatus, len(states)),
+		OpenBreakers: openBreakers,
+	}
+
+	var wg sync.WaitGroup
+	for i, s := range states {
+		wg.Add(1)
+		go func(i int, s *dependencyState) {
+			defer wg.Done()
+			report.Dependencies[i] = s.run(ctx)
+		}(i, s)
+	}
+	wg.Wait()
+
+	for _, d := range report.Dependencies {
+		if !d.Healthy {
+			report.Ready = false
+		}
+	}
+	sort.Strings(report.OpenBreakers)
+	sort.Slice(report.Dependencies, func(i, j int) bool {
+		return report.Dependencies[i].Name < report.Dependencies[j].Name
+	})
+	return report
+}
+
+// run chạy kiểm tra một lần và cập nhật số lần lỗi liên tiếp
+func (s *dependencyState) run(ctx context.Context) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, s.check.Timeout)
+	defer cancel()
+	err := s.check.Check(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.failures++
+		s.lastErr = err.Error()
+	} else {
+		s.failures = 0
+		s.lastErr = ""
+	}
+	return DependencyStatus{
+		Name:                s.check.Name,
+		Healthy:             s.failures < s.check.FailureThreshold,
+		ConsecutiveFailures: s.failures,
+		Error:               s.lastErr,
+	}
+}
@@ -0,0 +1,306 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReloadingListConfig cấu hình cho NewReloadingList
+type ReloadingListConfig struct {
+	// Source là đường dẫn file hoặc URL http(s) chứa danh sách, mỗi dòng một entry,
+	// dòng trống và phần sau "#" bị bỏ qua
+	Source   string
+	Interval time.Duration // Chu kỳ tải lại, mặc định 1 phút
+	Client   *http.Client  // HTTP client khi Source là URL, mặc định timeout 10s
+	MaxBytes int64         // Kích thước tối đa của nguồn, lớn hơn thì lần tải bị coi là lỗi; mặc định 10 MiB
+}
+
+// Validate kiểm tra tính hợp lệ của ReloadingListConfig
+func (c ReloadingListConfig) Validate() error {
+	var errs configErrors
+	if c.Source == "" {
+		errs.addf("Source is required")
+	}
+	if c.Interval < 0 {
+		errs.addf("Interval must not be negative, got %v", c.Interval)
+	}
+	if c.MaxBytes < 0 {
+		errs.addf("MaxBytes must not be negative, got %d", c.MaxBytes)
+	}
+	return errs.err()
+}
+
+// listSnapshot là nội dung danh sách tại một lần tải, không thay đổi sau khi tạo
+type listSnapshot struct {
+	entries  []string
+	exact    map[string]bool
+	networks []*net.IPNet
+	loadedAt time.Time
+}
+
+// ReloadingList là danh sách (IP, CIDR, user agent, rule, ...) được tải từ file
+// hoặc URL và tự động tải lại theo chu kỳ. Nội dung mới được swap nguyên khối,
+// request đang xử lý luôn thấy một phiên bản nhất quán. Lần tải lại lỗi được log
+// và danh sách cũ được giữ nguyên.
+type ReloadingList struct {
+	config       ReloadingListConfig
+	current      atomic.Pointer[listSnapshot]
+	reloadMu     sync.Mutex // Tuần tự hoá các lần Reload, bảo vệ etag/lastModified
+	lastModified string
+	etag         string
+	done         chan struct{}
+	closeOnce    sync.Once
+}
+
+// NewReloadingList tải danh sách lần đầu và khởi động việc tải lại định kỳ.
+// Trả về lỗi nếu lần tải đầu tiên thất bại, để service không khởi động với
+// danh sách rỗng ngoài ý muốn. Gọi Close để dừng tải lại.
+func NewReloadingList(config ReloadingListConfig) (*ReloadingList, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Interval == 0 {
+		config.Interval = time.Minute
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.MaxBytes == 0 {
+		config.MaxBytes = 10 << 20
+	}
+
+	l := &ReloadingList{config: config, done: make(chan struct{})}
+	if err := l.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := l.Reload(context.Background()); err != nil {
+					logMessage("[LIST] reload of %s failed, keeping previous list: %v", config.Source, err)
+				}
+			case <-l.done:
+				return
+			}
+		}
+	}()
+	return l, nil
+}
+
+// Close dừng việc tải lại định kỳ, danh sách hiện tại vẫn dùng được
+func (l *ReloadingList) Close() {
+	l.closeOnce.Do(func() { close(l.done) })
+}
+
+// Reload tải lại danh sách ngay lập tức
+func (l *ReloadingList) Reload(ctx context.Context) error {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	body, validators, err := l.fetch(ctx)
+	if err != nil || body == nil {
+		return err
+	}
+	defer body.Close()
+
+	snap, err := parseList(&maxBytesReader{r: body, remaining: l.config.MaxBytes})
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", l.config.Source, err)
+	}
+	old := l.current.Swap(snap)
+	// Chỉ ghi nhận ETag/Last-Modified khi nội dung đã được áp dụng, nếu không lần
+	// tải lỗi sẽ khiến các lần sau nhận 304 và giữ mãi danh sách cũ
+	l.etag, l.lastModified = validators.etag, validators.lastModified
+	if old != nil && len(old.entries) != len(snap.entries) {
+		logMessage("[LIST] reloaded %s: %d entries (was %d)", l.config.Source, len(snap.entries), len(old.entries))
+	}
+	return nil
+}
+
+// listValidators là ETag/Last-Modified của một lần tải qua HTTP
+type listValidators struct {
+	etag         string
+	lastModified string
+}
+
+// fetch mở nguồn dữ liệu; trả về nil body khi nguồn HTTP báo chưa thay đổi (304)
+func (l *ReloadingList) fetch(ctx context.Context) (io.ReadCloser, listValidators, error) {
+	source := l.config.Source
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, listValidators{}, err
+		}
+		return f, listValidators{}, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, listValidators{}, err
+	}
+	if l.etag != "" {
+		req.Header.Set("If-None-Match", l.etag)
+	}
+	if l.lastModified != "" {
+		req.Header.Set("If-Modified-Since", l.lastModified)
+	}
+	resp, err := l.config.Client.Do(req)
+	if err != nil {
+		return nil, listValidators{}, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && l.current.Load() != nil:
+		resp.Body.Close()
+		return nil, listValidators{}, nil
+	case resp.StatusCode != http.StatusOK:
+		resp.Body.Close()
+		return nil, listValidators{}, fmt.Errorf("fetch %s: unexpected status %s", source, resp.Status)
+	}
+	validators := listValidators{etag: resp.Header.Get("ETag"), lastModified: resp.Header.Get("Last-Modified")}
+	return resp.Body, validators, nil
+}
+
+// errListTooLarge được trả về khi nguồn lớn hơn ReloadingListConfig.MaxBytes
+var errListTooLarge = errors.New("list exceeds MaxBytes")
+
+// maxBytesReader đọc tối đa remaining byte, trả về errListTooLarge nếu nguồn còn dữ liệu
+type maxBytesReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+// Read implements io.Reader
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.remaining <= 0 {
+		// Thử đọc thêm một byte để phân biệt nguồn vừa đúng giới hạn với nguồn quá lớn
+		var probe [1]byte
+		if n, err := m.r.Read(probe[:]); n > 0 {
+			return 0, errListTooLarge
+		} else if err != nil {
+			return 0, err
+		}
+		return 0, nil
+	}
+	if int64(len(p)) > m.remaining {
+		p = p[:m.remaining]
+	}
+	n, err := m.r.Read(p)
+	m.remaining -= int64(n)
+	return n, err
+}
+
+// parseList đọc từng dòng, tách các entry dạng CIDR để so khớp theo dải IP
+func parseList(r io.Reader) (*listSnapshot, error) {
+	snap := &listSnapshot{exact: make(map[string]bool), loadedAt: time.Now()}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		snap.entries = append(snap.entries, line)
+		if _, network, err := net.ParseCIDR(line); err == nil {
+			snap.networks = append(snap.networks, network)
+			continue
+		}
+		snap.exact[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Contains kiểm tra value có khớp chính xác một entry trong danh sách
+func (l *ReloadingList) Contains(value string) bool {
+	return l.current.Load().exact[value]
+}
+
+// ContainsIP kiểm tra IP có nằm trong danh sách (khớp chính xác hoặc thuộc một dải CIDR)
+func (l *ReloadingList) ContainsIP(ip string) bool {
+	snap := l.current.Load()
+	if snap.exact[ip] {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range snap.networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries trả về bản sao các entry hiện tại theo thứ tự trong nguồn
+func (l *ReloadingList) Entries() []string {
+	return append([]string(nil), l.current.Load().entries...)
+}
+
+// LoadedAt trả về thời điểm danh sách hiện tại được tải
+func (l *ReloadingList) LoadedAt() time.Time {
+	return l.current.Load().loadedAt
+}
+
+// IPFilterConfig cấu hình cho IPFilterMiddleware
+type IPFilterConfig struct {
+	Allow *ReloadingList // Nếu có, chỉ IP trong danh sách được đi tiếp
+	Deny  *ReloadingList // IP trong danh sách bị từ chối
+	// Blocklist là blocklist động (vd. do honeypot/fingerprint thêm vào), kiểm tra cùng Deny
+	Blocklist *IPBlocklist
+}
+
+// Validate kiểm tra tính hợp lệ của IPFilterConfig
+func (c IPFilterConfig) Validate() error {
+	if c.Allow == nil && c.Deny == nil && c.Blocklist == nil {
+		return errors.New("at least one of Allow, Deny or Blocklist is required")
+	}
+	return nil
+}
+
+// IPFilterMiddleware trả về middleware lọc IP theo allowlist/denylist tải lại được
+// (xem ReloadingList), trả về 403 cho IP không được phép. Deny được ưu tiên hơn Allow.
+func IPFilterMiddleware(config IPFilterConfig) gin.HandlerFunc {
+	mustValidate("IPFilter", config)
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		reason := ""
+		switch {
+		case config.Deny != nil && config.Deny.ContainsIP(ip):
+			reason = "IP in deny list"
+		case config.Blocklist != nil && config.Blocklist.IsBlocked(ip):
+			reason = "blocked IP"
+		case config.Allow != nil && !config.Allow.ContainsIP(ip):
+			reason = "IP not in allow list"
+		}
+		if reason != "" {
+			AbortWithReason(c, 403, "ip_filter", reason, gin.H{
+				"message": Message(c, MessageForbidden),
+			})
+			return
+		}
+		c.Next()
+	}
+}
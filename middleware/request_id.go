@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kimxuanhong/go-logger/logger"
+)
+
+// maxRequestIDLength là độ dài tối đa của request ID nhận từ header
+const maxRequestIDLength = 128
+
+// RequestIDConfig cấu hình cho RequestIDMiddlewareWithConfig
+type RequestIDConfig struct {
+	Header         string        // Tên header chứa request ID, mặc định "X-Request-ID"
+	Generator      func() string // Hàm tạo request ID, mặc định UUID v4
+	TrustIncoming  bool          // Dùng lại request ID từ header của request nếu hợp lệ
+	ResponseHeader bool          // Ghi request ID vào header của response
+}
+
+// Validate kiểm tra tính hợp lệ của RequestIDConfig
+func (c RequestIDConfig) Validate() error {
+	var errs configErrors
+	errs.checkHeaderName("Header", c.Header)
+	return errs.err()
+}
+
+// DefaultRequestIDConfig trả về cấu hình mặc định: tạo UUID v4 cho mỗi request và
+// ghi vào header X-Request-ID của response. Request ID từ client không được dùng
+// lại (client có thể giả mạo để trộn log); bật TrustIncoming khi service nằm sau
+// gateway đã gán ID.
+func DefaultRequestIDConfig() RequestIDConfig {
+	return RequestIDConfig{
+		Header:         "X-Request-ID",
+		Generator:      uuid.NewString,
+		ResponseHeader: true,
+	}
+}
+
+// RequestIDMiddleware trả về middleware gán request ID cho request ngay từ đầu chain
+// với cấu hình mặc định. Nên đặt middleware này đầu tiên để mọi middleware phía sau
+// (kể cả recovery khi có panic) dùng chung một request ID.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return RequestIDMiddlewareWithConfig(DefaultRequestIDConfig())
+}
+
+// RequestIDMiddlewareWithConfig trả về middleware gán request ID theo cấu hình.
+// Request ID được lưu trong gin.Context (đọc qua RequestID) và trong
+// context của http.Request (đọc qua RequestIDFromContext), để các tính năng
+// không liên quan tới logging (auth, tracing, error response) cũng dùng được.
+func RequestIDMiddlewareWithConfig(config RequestIDConfig) gin.HandlerFunc {
+	mustValidate("RequestID", config)
+	if config.Header == "" {
+		config.Header = "X-Request-ID"
+	}
+	if config.Generator == nil {
+		config.Generator = uuid.NewString
+	}
+
+	return func(c *gin.Context) {
+		requestID := RequestID(c)
+		if requestID == "" && config.TrustIncoming {
+			if incoming := c.GetHeader(config.Header); validRequestID(incoming) {
+				requestID = incoming
+			}
+		}
+		if requestID == "" {
+			requestID = config.Generator()
+		}
+
+		setRequestID(c, requestID)
+		if config.ResponseHeader {
+			c.Header(config.Header, requestID)
+		}
+		c.Next()
+	}
+}
+
+// RequestIDFromContext trả về request ID lưu trong context.Context của request
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(logger.RequestIDKey).(string)
+	return requestID
+}
+
+// ensureRequestID trả về request ID hiện tại. Khi chain không có RequestIDMiddleware,
+// ID mới được tạo và lưu như RequestIDMiddleware với cấu hình mặc định: vào cả hai
+// context và header X-Request-ID của response (nếu response chưa được ghi).
+func ensureRequestID(c *gin.Context) string {
+	requestID := RequestID(c)
+	if requestID == "" {
+		requestID = uuid.NewString()
+		setRequestID(c, requestID)
+		if !c.Writer.Written() {
+			c.Header("X-Request-ID", requestID)
+		}
+	}
+	return requestID
+}
+
+// setRequestID lưu request ID vào gin.Context và context của http.Request
+func setRequestID(c *gin.Context, requestID string) {
+	c.Set(ContextKeyRequestID, requestID)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), logger.RequestIDKey, requestID))
+}
+
+// validRequestID kiểm tra request ID từ client: không rỗng, không quá dài và
+// chỉ chứa ký tự in được (tránh log injection)
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
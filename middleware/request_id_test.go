@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDRouter trả về router ghi lại request ID handler nhìn thấy qua cả hai context
+func requestIDRouter(config RequestIDConfig, seen *[2]string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddlewareWithConfig(config))
+	r.GET("/orders", func(c *gin.Context) {
+		seen[0], seen[1] = RequestID(c), RequestIDFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestRequestIDIgnoresIncomingByDefault(t *testing.T) {
+	var seen [2]string
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("X-Request-ID", "spoofed-id")
+	w := httptest.NewRecorder()
+	requestIDRouter(DefaultRequestIDConfig(), &seen).ServeHTTP(w, req)
+
+	if seen[0] == "" || seen[0] == "spoofed-id" {
+		t.Fatalf("request ID: got %q, want a generated ID", seen[0])
+	}
+	if seen[1] != seen[0] || w.Header().Get("X-Request-ID") != seen[0] {
+		t.Fatalf("request ID mismatch: gin %q, context %q, header %q", seen[0], seen[1], w.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestRequestIDTrustIncomingValidatesHeader(t *testing.T) {
+	config := RequestIDConfig{Header: "X-Trace-Id", Generator: func() string { return "generated" }, TrustIncoming: true}
+	cases := map[string]string{
+		"gateway-42": "gateway-42",
+		"":           "generated",
+		"có dấu":     "generated",
+		"evil\tid":   "generated",
+		strings.Repeat("a", maxRequestIDLength+1): "generated",
+		strings.Repeat("a", maxRequestIDLength):   strings.Repeat("a", maxRequestIDLength),
+	}
+	for incoming, want := range cases {
+		var seen [2]string
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.Header.Set("X-Trace-Id", incoming)
+		w := httptest.NewRecorder()
+		requestIDRouter(config, &seen).ServeHTTP(w, req)
+
+		if seen[0] != want || seen[1] != want {
+			t.Fatalf("incoming %q: got %q/%q, want %q", incoming, seen[0], seen[1], want)
+		}
+		if got := w.Header().Get("X-Trace-Id"); got != "" {
+			t.Fatalf("incoming %q: response header got %q, want none without ResponseHeader", incoming, got)
+		}
+	}
+}
+
+func TestEnsureRequestIDWithoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var first, second string
+	r := gin.New()
+	r.GET("/orders", func(c *gin.Context) {
+		first, second = ensureRequestID(c), ensureRequestID(c)
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if first == "" || second != first || w.Header().Get("X-Request-ID") != first {
+		t.Fatalf("ensureRequestID: got %q then %q, header %q", first, second, w.Header().Get("X-Request-ID"))
+	}
+}
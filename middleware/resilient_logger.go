@@ -0,0 +1,325 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LogKind identifies which Logger method a LogRecord corresponds to
+type LogKind string
+
+const (
+	LogKindRequest  LogKind = "request"
+	LogKindResponse LogKind = "response"
+	LogKindError    LogKind = "error"
+	LogKindMessage  LogKind = "message"
+)
+
+// LogRecord is a single call to a Logger, used by loggers that wrap or queue other loggers
+type LogRecord struct {
+	Kind      LogKind
+	Entry     *LogEntry // Set for request and response records
+	RequestID string    // Set for error records
+	Err       error     // Set for error records
+	Message   string    // Set for message records
+}
+
+// FallibleLogger is an optional interface for loggers whose backend can fail
+// (file, network, broker), reporting the failure instead of swallowing it
+type FallibleLogger interface {
+	TryLog(record LogRecord) error
+}
+
+// LoggerStats is an optional interface for loggers that expose counters,
+// reported under "logger" in GetMetrics when implemented by the default logger,
+// and by the metrics endpoint when implemented by the logger of the request's Core
+type LoggerStats interface {
+	LoggerStats() map[string]interface{}
+}
+
+// dispatchLog delivers a record to a Logger, using TryLog when available
+func dispatchLog(l Logger, record LogRecord) error {
+	if fl, ok := l.(FallibleLogger); ok {
+		return fl.TryLog(record)
+	}
+	switch record.Kind {
+	case LogKindRequest:
+		l.LogRequest(*record.Entry)
+	case LogKindResponse:
+		l.LogResponse(*record.Entry)
+	case LogKindError:
+		l.LogError(record.RequestID, record.Err)
+	case LogKindMessage:
+		if ml, ok := l.(MessageLogger); ok {
+			ml.LogMessage(record.Message)
+		}
+	}
+	return nil
+}
+
+// errLoggerTimeout is reported when the backend does not return within Timeout
+var errLoggerTimeout = errors.New("logger backend timed out")
+
+// ResilientLoggerConfig configures NewResilientLogger
+type ResilientLoggerConfig struct {
+	Timeout          time.Duration // Max time a backend call may take, default 500ms
+	FailureThreshold int           // Consecutive failures that open the circuit, default 5
+	Cooldown         time.Duration // Time the circuit stays open before a trial call, default 30s
+	MaxPending       int           // Max timed-out backend calls still running before new calls fail fast, default 16
+	Fallback         Logger        // Receives entries the backend could not take, default JSON lines on stderr
+}
+
+// Validate checks the ResilientLoggerConfig for invalid values
+func (c ResilientLoggerConfig) Validate() error {
+	var errs configErrors
+	if c.Timeout < 0 || c.Cooldown < 0 {
+		errs.addf("Timeout/Cooldown must not be negative")
+	}
+	if c.FailureThreshold < 0 || c.MaxPending < 0 {
+		errs.addf("FailureThreshold/MaxPending must not be negative")
+	}
+	return errs.err()
+}
+
+// ResilientLogger wraps a Logger so that a failing or blocking backend can
+// never stall request handling. Calls that panic, return an error (see
+// FallibleLogger) or exceed the timeout count as failures; after
+// FailureThreshold consecutive failures the circuit opens and entries go
+// straight to the fallback until the cooldown elapses.
+type ResilientLogger struct {
+	backend Logger
+	config  ResilientLoggerConfig
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+
+	pending   atomic.Int32
+	delivered atomic.Uint64
+	failed    atomic.Uint64
+	fallbacks atomic.Uint64
+	dropped   atomic.Uint64
+	opened    atomic.Uint64
+}
+
+// NewResilientLogger wraps backend with timeout, circuit breaking and fallback
+func NewResilientLogger(backend Logger, config ResilientLoggerConfig) *ResilientLogger {
+	mustValidate("ResilientLogger", config)
+	if config.Timeout == 0 {
+		config.Timeout = 500 * time.Millisecond
+	}
+	if config.FailureThreshold == 0 {
+		config.FailureThreshold = 5
+	}
+	if config.Cooldown == 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	if config.MaxPending == 0 {
+		config.MaxPending = 16
+	}
+	if config.Fallback == nil {
+		config.Fallback = stderrLogger{}
+	}
+	return &ResilientLogger{backend: backend, config: config}
+}
+
+// LogRequest implements Logger interface for ResilientLogger
+func (l *ResilientLogger) LogRequest(entry LogEntry) {
+	l.log(LogRecord{Kind: LogKindRequest, Entry: &entry})
+}
+
+// LogResponse implements Logger interface for ResilientLogger
+func (l *ResilientLogger) LogResponse(entry LogEntry) {
+	l.log(LogRecord{Kind: LogKindResponse, Entry: &entry})
+}
+
+// LogError implements Logger interface for ResilientLogger
+func (l *ResilientLogger) LogError(requestID string, err error) {
+	l.log(LogRecord{Kind: LogKindError, RequestID: requestID, Err: err})
+}
+
+// LogMessage implements MessageLogger interface for ResilientLogger
+func (l *ResilientLogger) LogMessage(message string) {
+	l.log(LogRecord{Kind: LogKindMessage, Message: message})
+}
+
+// TryLog implements FallibleLogger interface for ResilientLogger, reporting
+// whether the record reached the backend
+func (l *ResilientLogger) TryLog(record LogRecord) error {
+	if !l.allow() {
+		l.fallback(record)
+		return errors.New("logger circuit open")
+	}
+	err := l.call(record)
+	l.report(err)
+	if err != nil {
+		l.fallback(record)
+	}
+	return err
+}
+
+// LoggerStats implements LoggerStats interface for ResilientLogger
+func (l *ResilientLogger) LoggerStats() map[string]interface{} {
+	l.mu.Lock()
+	open := time.Now().Before(l.openUntil)
+	l.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"delivered":     l.delivered.Load(),
+		"failed":        l.failed.Load(),
+		"fallback":      l.fallbacks.Load(),
+		"dropped":       l.dropped.Load(),
+		"circuit_open":  open,
+		"circuit_trips": l.opened.Load(),
+		"pending_calls": l.pending.Load(),
+	}
+	if s, ok := l.backend.(LoggerStats); ok {
+		stats["backend"] = s.LoggerStats()
+	}
+	return stats
+}
+
+// log delivers a record without reporting the outcome
+func (l *ResilientLogger) log(record LogRecord) {
+	_ = l.TryLog(record)
+}
+
+// allow reports whether the backend may be called: the circuit is closed,
+// or it is half-open and no trial call is in progress
+func (l *ResilientLogger) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.openUntil.IsZero() {
+		return true
+	}
+	if time.Now().Before(l.openUntil) || l.trial {
+		return false
+	}
+	l.trial = true
+	return true
+}
+
+// report records the outcome of a backend call and opens the circuit when needed
+func (l *ResilientLogger) report(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.trial = false
+	if err == nil {
+		l.delivered.Add(1)
+		l.failures = 0
+		l.openUntil = time.Time{}
+		return
+	}
+	l.failed.Add(1)
+	l.failures++
+	if l.failures >= l.config.FailureThreshold {
+		if l.openUntil.IsZero() || time.Now().After(l.openUntil) {
+			l.opened.Add(1)
+			fmt.Fprintf(os.Stderr, "resilient logger: backend failing (%v), using fallback for %v\n", err, l.config.Cooldown)
+		}
+		l.openUntil = time.Now().Add(l.config.Cooldown)
+	}
+}
+
+// Call states shared by call and its backend goroutine
+const (
+	callRunning int32 = iota
+	callFinished
+	callTimedOut
+)
+
+// call runs the backend call with a timeout, recovering panics. A call that
+// times out keeps running in the background and counts as pending until it
+// returns; while MaxPending such calls are outstanding new calls fail
+// immediately. Calls that are merely in flight do not count, so concurrency
+// alone never trips the limit.
+func (l *ResilientLogger) call(record LogRecord) error {
+	if int(l.pending.Load()) >= l.config.MaxPending {
+		return errLoggerTimeout
+	}
+
+	var state atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if !state.CompareAndSwap(callRunning, callFinished) {
+				l.pending.Add(-1)
+			}
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("logger backend panicked: %v", r)
+			}
+		}()
+		done <- dispatchLog(l.backend, record)
+	}()
+
+	timer := time.NewTimer(l.config.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		// Count the call before marking it timed out so the goroutine's
+		// decrement can never run first
+		l.pending.Add(1)
+		if !state.CompareAndSwap(callRunning, callTimedOut) {
+			// The backend returned as the timer fired
+			l.pending.Add(-1)
+			return <-done
+		}
+		return errLoggerTimeout
+	}
+}
+
+// fallback writes a record the backend could not take; it counts as dropped
+// when the fallback itself fails
+func (l *ResilientLogger) fallback(record LogRecord) {
+	l.fallbacks.Add(1)
+	defer func() {
+		if recover() != nil {
+			l.dropped.Add(1)
+		}
+	}()
+	if err := dispatchLog(l.config.Fallback, record); err != nil {
+		l.dropped.Add(1)
+	}
+}
+
+// stderrLogger writes records as JSON lines on stderr, the last-resort fallback
+type stderrLogger struct{}
+
+// LogRequest implements Logger interface for stderrLogger
+func (s stderrLogger) LogRequest(entry LogEntry) {
+	_ = s.TryLog(LogRecord{Kind: LogKindRequest, Entry: &entry})
+}
+
+// LogResponse implements Logger interface for stderrLogger
+func (s stderrLogger) LogResponse(entry LogEntry) {
+	_ = s.TryLog(LogRecord{Kind: LogKindResponse, Entry: &entry})
+}
+
+// LogError implements Logger interface for stderrLogger
+func (s stderrLogger) LogError(requestID string, err error) {
+	_ = s.TryLog(LogRecord{Kind: LogKindError, RequestID: requestID, Err: err})
+}
+
+// TryLog implements FallibleLogger interface for stderrLogger
+func (stderrLogger) TryLog(record LogRecord) error {
+	r := fileLogRecord{Time: time.Now(), Type: string(record.Kind), Entry: record.Entry, ID: record.RequestID, Msg: record.Message}
+	if record.Err != nil {
+		r.Error = record.Err.Error()
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stderr, string(data))
+	return err
+}
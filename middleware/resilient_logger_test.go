@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowLogger là Logger mỗi lần gọi mất delay, hoặc chặn tới khi release đóng
+type slowLogger struct {
+	delay   time.Duration
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (l *slowLogger) wait() {
+	l.calls.Add(1)
+	if l.release != nil {
+		<-l.release
+		return
+	}
+	time.Sleep(l.delay)
+}
+
+func (l *slowLogger) LogRequest(LogEntry)    { l.wait() }
+func (l *slowLogger) LogResponse(LogEntry)   { l.wait() }
+func (l *slowLogger) LogError(string, error) { l.wait() }
+
+// discardLogger bỏ qua mọi entry
+type discardLogger struct{}
+
+func (discardLogger) LogRequest(LogEntry)    {}
+func (discardLogger) LogResponse(LogEntry)   {}
+func (discardLogger) LogError(string, error) {}
+
+func TestResilientLoggerConcurrentHealthyCalls(t *testing.T) {
+	backend := &slowLogger{delay: 20 * time.Millisecond}
+	l := NewResilientLogger(backend, ResilientLoggerConfig{
+		Timeout:    time.Second,
+		MaxPending: 2,
+		Fallback:   discardLogger{},
+	})
+
+	// Nhiều call đồng thời hơn MaxPending nhưng không call nào timeout
+	const callers = 50
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.TryLog(LogRecord{Kind: LogKindResponse, Entry: &LogEntry{}}); err != nil {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := failed.Load(); n != 0 {
+		t.Fatalf("got %d failed calls, want 0", n)
+	}
+	stats := l.LoggerStats()
+	if stats["circuit_open"] != false || stats["delivered"] != uint64(callers) {
+		t.Fatalf("stats: got %v, want circuit closed and %d delivered", stats, callers)
+	}
+}
+
+func TestResilientLoggerLimitsTimedOutCalls(t *testing.T) {
+	backend := &slowLogger{release: make(chan struct{})}
+	l := NewResilientLogger(backend, ResilientLoggerConfig{
+		Timeout:          10 * time.Millisecond,
+		MaxPending:       2,
+		FailureThreshold: 100,
+		Fallback:         discardLogger{},
+	})
+
+	for i := 0; i < 4; i++ {
+		if err := l.TryLog(LogRecord{Kind: LogKindResponse, Entry: &LogEntry{}}); err != errLoggerTimeout {
+			t.Fatalf("call %d: got %v, want errLoggerTimeout", i, err)
+		}
+	}
+	// Hai call đầu timeout và còn chạy; hai call sau bị từ chối mà không gọi backend
+	if n := backend.calls.Load(); n != 2 {
+		t.Fatalf("backend calls: got %d, want 2 (MaxPending)", n)
+	}
+	if n := l.LoggerStats()["pending_calls"]; n != int32(2) {
+		t.Fatalf("pending calls: got %v, want 2", n)
+	}
+
+	close(backend.release)
+	deadline := time.Now().Add(time.Second)
+	for l.pending.Load() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := l.pending.Load(); n != 0 {
+		t.Fatalf("pending calls after release: got %d, want 0", n)
+	}
+}
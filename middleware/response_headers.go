@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultSecurityHeaders là header bảo mật mặc định của ResponseHeadersMiddleware
+var DefaultSecurityHeaders = map[string]string{
+	"X-Content-Type-Options": "nosniff",
+	"X-Frame-Options":        "DENY",
+	"Referrer-Policy":        "no-referrer",
+}
+
+// ResponseHeadersConfig cấu hình cho ResponseHeadersMiddleware
+type ResponseHeadersConfig struct {
+	// RequestIDHeader là header mang request ID, mặc định "X-Request-ID"
+	RequestIDHeader string
+	// CacheControl được set khi handler không tự set Cache-Control, mặc định "no-store"
+	CacheControl string
+	// ErrorCacheControl luôn được set cho response lỗi (>= 400) để lỗi không bị
+	// cache, mặc định "no-store"
+	ErrorCacheControl string
+	// SecurityHeaders được set khi response chưa có header đó; nil là
+	// DefaultSecurityHeaders, map rỗng là không set header nào
+	SecurityHeaders map[string]string
+}
+
+// Validate kiểm tra tính hợp lệ của ResponseHeadersConfig
+func (c ResponseHeadersConfig) Validate() error {
+	var errs configErrors
+	errs.checkHeaderName("RequestIDHeader", c.RequestIDHeader)
+	for name := range c.SecurityHeaders {
+		errs.checkHeaderName("SecurityHeaders", name)
+	}
+	return errs.err()
+}
+
+// ResponseHeadersMiddleware trả về middleware áp dụng chính sách header cho mọi
+// response tại một chỗ duy nhất: Content-Language (theo LocaleMiddleware),
+// request ID, Cache-Control và header bảo mật. Header được set ngay trước khi
+// response được gửi, nên cả response lỗi do middleware khác tạo ra (429, 503,
+// panic, ...) cũng có đủ. Nên đặt middleware này đầu tiên trong chain.
+func ResponseHeadersMiddleware(config ResponseHeadersConfig) gin.HandlerFunc {
+	mustValidate("ResponseHeaders", config)
+	if config.RequestIDHeader == "" {
+		config.RequestIDHeader = "X-Request-ID"
+	}
+	if config.CacheControl == "" {
+		config.CacheControl = "no-store"
+	}
+	if config.ErrorCacheControl == "" {
+		config.ErrorCacheControl = "no-store"
+	}
+	if config.SecurityHeaders == nil {
+		config.SecurityHeaders = DefaultSecurityHeaders
+	}
+
+	return func(c *gin.Context) {
+		writer := &headerFinalizer{ResponseWriter: c.Writer, ctx: c, config: &config}
+		c.Writer = writer
+		c.Next()
+		// Response không có body (ví dụ c.Status(204)) được gin flush trực tiếp,
+		// không qua wrapper: áp dụng header trước khi handler trả về
+		writer.finalize()
+	}
+}
+
+// headerFinalizer là wrapper set header chính sách ngay trước khi header được gửi
+type headerFinalizer struct {
+	gin.ResponseWriter
+	ctx       *gin.Context
+	config    *ResponseHeadersConfig
+	finalized bool
+}
+
+// finalize set các header chính sách theo status cuối cùng, chỉ chạy một lần
+func (w *headerFinalizer) finalize() {
+	if w.finalized || w.ResponseWriter.Written() {
+		return
+	}
+	w.finalized = true
+	header := w.Header()
+
+	if locale := Locale(w.ctx); locale != "" {
+		if header.Get("Content-Language") == "" {
+			header.Set("Content-Language", locale)
+		}
+		if !headerContains(header, "Vary", "Accept-Language") {
+			header.Add("Vary", "Accept-Language")
+		}
+	}
+	if requestID := RequestID(w.ctx); requestID != "" && header.Get(w.config.RequestIDHeader) == "" {
+		header.Set(w.config.RequestIDHeader, requestID)
+	}
+	if w.ResponseWriter.Status() >= http.StatusBadRequest {
+		header.Set("Cache-Control", w.config.ErrorCacheControl)
+	} else if header.Get("Cache-Control") == "" {
+		header.Set("Cache-Control", w.config.CacheControl)
+	}
+	for name, value := range w.config.SecurityHeaders {
+		if header.Get(name) == "" {
+			header.Set(name, value)
+		}
+	}
+}
+
+// headerContains kiểm tra header (dạng danh sách phân cách bởi dấu phẩy) có chứa token
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteHeaderNow set header chính sách trước khi header được gửi
+func (w *headerFinalizer) WriteHeaderNow() {
+	w.finalize()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write set header chính sách trước khi ghi body đầu tiên
+func (w *headerFinalizer) Write(b []byte) (int, error) {
+	w.finalize()
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString set header chính sách trước khi ghi body đầu tiên
+func (w *headerFinalizer) WriteString(s string) (int, error) {
+	w.finalize()
+	return w.ResponseWriter.WriteString(s)
+}
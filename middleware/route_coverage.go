@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeStat là thống kê traffic của một route
+type routeStat struct {
+	Hits     uint64    `json:"hits"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// RouteCoverageEntry là trạng thái traffic của một route đã đăng ký
+type RouteCoverageEntry struct {
+	Method   string     `json:"method"`
+	Path     string     `json:"path"`
+	Hits     uint64     `json:"hits"`
+	LastSeen *time.Time `json:"last_seen,omitempty"` // nil nếu route chưa từng nhận traffic
+}
+
+// RecordRoute ghi nhận một request tới route (method + path pattern của gin)
+func (m *Metrics) RecordRoute(method, route string) {
+	if route == "" {
+		return
+	}
+	key := method + " " + route
+	now := time.Now()
+
+	m.mu.Lock()
+	if m.routeStats == nil {
+		m.routeStats = make(map[string]*routeStat)
+	}
+	stat, ok := m.routeStats[key]
+	if !ok {
+		stat = &routeStat{}
+		m.routeStats[key] = stat
+	}
+	stat.Hits++
+	stat.LastSeen = now
+	m.mu.Unlock()
+}
+
+// RouteCoverage trả về danh sách mọi route đã đăng ký trên engine kèm số request
+// và thời điểm nhận request gần nhất theo metrics toàn cục, giúp tìm các endpoint
+// không còn được dùng. Với engine gắn Core có Metrics riêng, dùng
+// Metrics.RouteCoverage của Core đó.
+func RouteCoverage(engine *gin.Engine) []RouteCoverageEntry {
+	return metrics.RouteCoverage(engine)
+}
+
+// RouteCoverage giống hàm RouteCoverage nhưng đọc số request từ m.
+// Các route chưa từng nhận traffic được xếp lên đầu.
+func (m *Metrics) RouteCoverage(engine *gin.Engine) []RouteCoverageEntry {
+	routes := engine.Routes()
+	entries := make([]RouteCoverageEntry, 0, len(routes))
+
+	m.mu.RLock()
+	for _, route := range routes {
+		entry := RouteCoverageEntry{Method: route.Method, Path: route.Path}
+		if stat, ok := m.routeStats[route.Method+" "+route.Path]; ok {
+			lastSeen := stat.LastSeen
+			entry.Hits = stat.Hits
+			entry.LastSeen = &lastSeen
+		}
+		entries = append(entries, entry)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Hits != entries[j].Hits {
+			return entries[i].Hits < entries[j].Hits
+		}
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Method < entries[j].Method
+	})
+	return entries
+}
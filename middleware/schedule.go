@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeWindow là một khung giờ trong ngày, áp dụng cho các ngày trong tuần đã chọn
+type TimeWindow struct {
+	Days  []time.Weekday // Các ngày áp dụng, rỗng là mọi ngày
+	Start string         // Giờ bắt đầu "HH:MM"
+	End   string         // Giờ kết thúc "HH:MM"; nhỏ hơn Start nghĩa là qua nửa đêm
+}
+
+// ScheduleRule là lịch cho phép truy cập một route hoặc nhóm route
+type ScheduleRule struct {
+	Name     string         // Tên rule, hiển thị trong response và admin API
+	Windows  []TimeWindow   // Các khung giờ cho phép truy cập
+	Location *time.Location // Múi giờ của lịch, mặc định time.Local
+}
+
+// Validate kiểm tra tính hợp lệ của ScheduleRule
+func (r ScheduleRule) Validate() error {
+	var errs configErrors
+	if r.Name == "" {
+		errs.addf("Name is required")
+	}
+	if len(r.Windows) == 0 {
+		errs.addf("Windows: at least one window is required, otherwise the route is never accessible")
+	}
+	for i, w := range r.Windows {
+		start, errStart := parseClock(w.Start)
+		end, errEnd := parseClock(w.End)
+		if errStart != nil {
+			errs.addf("Windows[%d].Start: %q is not a valid HH:MM time", i, w.Start)
+		}
+		if errEnd != nil {
+			errs.addf("Windows[%d].End: %q is not a valid HH:MM time", i, w.End)
+		}
+		if errStart == nil && errEnd == nil && start == end {
+			errs.addf("Windows[%d]: Start and End are equal (%s), the window is empty", i, w.Start)
+		}
+	}
+	return errs.err()
+}
+
+// ScheduleState là trạng thái hiện tại của một ScheduleRule
+type ScheduleState struct {
+	Name       string     `json:"name"`
+	Open       bool       `json:"open"`
+	NextChange *time.Time `json:"next_change,omitempty"` // Thời điểm trạng thái thay đổi tiếp theo
+}
+
+// parsedWindow là TimeWindow đã được parse sang phút trong ngày
+type parsedWindow struct {
+	days       map[time.Weekday]bool
+	start, end int
+}
+
+// schedule là ScheduleRule đã được parse
+type schedule struct {
+	name     string
+	location *time.Location
+	windows  []parsedWindow
+}
+
+var (
+	schedulesMu sync.RWMutex
+	schedules   = make(map[string]*schedule)
+)
+
+// ScheduleMiddleware trả về middleware chỉ cho phép truy cập trong các khung giờ
+// của rule (ví dụ endpoint batch chỉ chạy ngoài giờ cao điểm). Ngoài khung giờ,
+// trả về 403 kèm tên lịch và thời điểm mở tiếp theo. Rule được đăng ký để
+// xem trạng thái qua admin API (ScheduleStates).
+//
+// Panic nếu rule không hợp lệ (xem ScheduleRule.Validate) hoặc Name đã được một
+// ScheduleMiddleware khác dùng, vì trạng thái trong admin API được tra theo Name.
+func ScheduleMiddleware(rule ScheduleRule) gin.HandlerFunc {
+	mustValidate("Schedule", rule)
+	sched := parseSchedule(rule)
+
+	schedulesMu.Lock()
+	_, duplicate := schedules[sched.name]
+	if !duplicate {
+		schedules[sched.name] = sched
+	}
+	schedulesMu.Unlock()
+	mustValidate("Schedule", validatorFunc(func() error {
+		if duplicate {
+			return fmt.Errorf("Name %q is already used by another schedule rule", rule.Name)
+		}
+		return nil
+	}))
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		if sched.open(now) {
+			c.Next()
+			return
+		}
+
+		body := gin.H{
+			"message":  Message(c, MessageOutsideSchedule),
+			"schedule": sched.name,
+		}
+		if next := sched.nextChange(now); next != nil {
+			body["next_open"] = next.Format(time.RFC3339)
+		}
+		AbortWithReason(c, http.StatusForbidden, "schedule", "outside schedule "+sched.name, body)
+	}
+}
+
+// ScheduleStates trả về trạng thái hiện tại của mọi ScheduleRule đã đăng ký
+func ScheduleStates() []ScheduleState {
+	now := time.Now()
+
+	schedulesMu.RLock()
+	states := make([]ScheduleState, 0, len(schedules))
+	for _, s := range schedules {
+		states = append(states, ScheduleState{Name: s.name, Open: s.open(now), NextChange: s.nextChange(now)})
+	}
+	schedulesMu.RUnlock()
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// parseSchedule parse ScheduleRule đã được Validate
+func parseSchedule(rule ScheduleRule) *schedule {
+	s := &schedule{name: rule.Name, location: rule.Location}
+	if s.location == nil {
+		s.location = time.Local
+	}
+	for _, w := range rule.Windows {
+		start, _ := parseClock(w.Start)
+		end, _ := parseClock(w.End)
+		pw := parsedWindow{start: start, end: end}
+		if len(w.Days) > 0 {
+			pw.days = make(map[time.Weekday]bool, len(w.Days))
+			for _, d := range w.Days {
+				pw.days[d] = true
+			}
+		}
+		s.windows = append(s.windows, pw)
+	}
+	return s
+}
+
+// parseClock chuyển "HH:MM" thành số phút trong ngày
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// open kiểm tra thời điểm now có nằm trong một khung giờ cho phép hay không
+func (s *schedule) open(now time.Time) bool {
+	now = now.In(s.location)
+	minute := now.Hour()*60 + now.Minute()
+	for _, w := range s.windows {
+		if w.start <= w.end {
+			if w.matchDay(now.Weekday()) && minute >= w.start && minute < w.end {
+				return true
+			}
+			continue
+		}
+		// Khung giờ qua nửa đêm: phần tối thuộc ngày hiện tại, phần sáng thuộc ngày hôm trước
+		if w.matchDay(now.Weekday()) && minute >= w.start {
+			return true
+		}
+		if w.matchDay(now.AddDate(0, 0, -1).Weekday()) && minute < w.end {
+			return true
+		}
+	}
+	return false
+}
+
+// nextChange trả về thời điểm trạng thái open thay đổi tiếp theo trong vòng 8 ngày
+func (s *schedule) nextChange(now time.Time) *time.Time {
+	now = now.In(s.location)
+	current := s.open(now)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+
+	var best *time.Time
+	for day := -1; day <= 8; day++ {
+		base := midnight.AddDate(0, 0, day)
+		for _, w := range s.windows {
+			for _, minute := range []int{w.start, w.end} {
+				candidate := base.Add(time.Duration(minute) * time.Minute)
+				if !candidate.After(now) || (best != nil && !candidate.Before(*best)) {
+					continue
+				}
+				if s.open(candidate) != current {
+					c := candidate
+					best = &c
+				}
+			}
+		}
+	}
+	return best
+}
+
+// matchDay kiểm tra khung giờ có áp dụng cho ngày d hay không
+func (w parsedWindow) matchDay(d time.Weekday) bool {
+	return w.days == nil || w.days[d]
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// at trả về thời điểm trong tuần bắt đầu từ thứ Hai 2026-10-12 (UTC)
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, 12+day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestScheduleOpen(t *testing.T) {
+	s := parseSchedule(ScheduleRule{
+		Name:     "batch",
+		Location: time.UTC,
+		Windows: []TimeWindow{
+			{Days: []time.Weekday{time.Monday}, Start: "09:00", End: "17:00"},
+			{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "02:00"},
+		},
+	})
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"before window", at(0, 8, 59), false},
+		{"window start", at(0, 9, 0), true},
+		{"window end is exclusive", at(0, 17, 0), false},
+		{"other day", at(1, 10, 0), false},
+		{"overnight evening", at(4, 23, 0), true},
+		{"overnight morning of next day", at(5, 1, 59), true},
+		{"overnight end", at(5, 2, 0), false},
+		{"morning of the window day itself", at(4, 1, 0), false},
+		{"converted to schedule location", time.Date(2026, 10, 12, 16, 0, 0, 0, time.FixedZone("UTC+7", 7*3600)), true},
+	}
+	for _, tt := range tests {
+		if got := s.open(tt.now); got != tt.want {
+			t.Errorf("%s: open(%v) = %v, want %v", tt.name, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNextChange(t *testing.T) {
+	s := parseSchedule(ScheduleRule{
+		Name:     "batch",
+		Location: time.UTC,
+		Windows: []TimeWindow{
+			{Days: []time.Weekday{time.Monday}, Start: "09:00", End: "17:00"},
+			{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "02:00"},
+		},
+	})
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"closed until window opens", at(0, 8, 0), at(0, 9, 0)},
+		{"open until window closes", at(0, 9, 0), at(0, 17, 0)},
+		{"closed until overnight window", at(0, 17, 0), at(4, 22, 0)},
+		{"overnight window closes next day", at(4, 23, 30), at(5, 2, 0)},
+		{"wraps to next week", at(5, 2, 0), at(7, 9, 0)},
+	}
+	for _, tt := range tests {
+		got := s.nextChange(tt.now)
+		if got == nil || !got.Equal(tt.want) {
+			t.Errorf("%s: nextChange(%v) = %v, want %v", tt.name, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleMiddlewareRejectsDuplicateName(t *testing.T) {
+	rule := ScheduleRule{Name: "duplicate-test", Windows: []TimeWindow{{Start: "09:00", End: "17:00"}}}
+	ScheduleMiddleware(rule)
+	defer func() {
+		schedulesMu.Lock()
+		delete(schedules, rule.Name)
+		schedulesMu.Unlock()
+	}()
+
+	msg := mustPanic(t, "second rule", func() { ScheduleMiddleware(rule) })
+	if !strings.Contains(msg, `Name "duplicate-test" is already used`) {
+		t.Fatalf("got panic %q, want duplicate name error", msg)
+	}
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// selfTestPath là path của route giả dùng cho self-test
+const selfTestPath = "/__selftest"
+
+// MiddlewareTiming là thời gian xử lý của một middleware trong self-test
+type MiddlewareTiming struct {
+	Name      string        `json:"name"`
+	Inclusive time.Duration `json:"inclusive_ns"` // Gồm cả các middleware phía sau
+	Self      time.Duration `json:"self_ns"`      // Chỉ riêng middleware này
+}
+
+// SelfTestReport là kết quả self-test của middleware stack
+type SelfTestReport struct {
+	Status      int                `json:"status"`
+	Duration    time.Duration      `json:"duration_ns"`
+	Middlewares []MiddlewareTiming `json:"middlewares"`
+	Issues      []string           `json:"issues"`
+}
+
+// funcSuffix khớp phần hậu tố closure (".func1", ".func1.2") trong tên function
+var funcSuffix = regexp.MustCompile(`(\.func\d+)+$`)
+
+// RunSelfTest gửi một request giả qua toàn bộ middleware toàn cục của engine
+// (dựng lại trên một engine riêng, tới một route giả) và báo cáo độ trễ của từng
+// middleware cùng các lỗi cấu hình phát hiện được, ví dụ cài response logger
+// mà không có request logger.
+//
+// Request giả được cô lập khỏi traffic thật: nó dùng Core riêng (logger bỏ qua
+// mọi entry, Metrics riêng) nên không xuất hiện trong log, GetMetrics hay các
+// MetricsSink; được đánh dấu dry-run nên TransactionMiddleware luôn rollback; và
+// đến từ 192.0.2.1 (dải TEST-NET) nên chỉ tiêu tốn rate limit của chính IP đó.
+// Middleware tự viết có thể nhận ra request này qua IsSelfTest.
+func RunSelfTest(engine *gin.Engine) SelfTestReport {
+	handlers := engine.Handlers
+	names := make([]string, len(handlers))
+	inclusive := make([]time.Duration, len(handlers))
+	for i, h := range handlers {
+		names[i] = handlerName(h)
+	}
+
+	isolated := &coreAttachment{core: &Core{Logger: selfTestLogger{}, Metrics: NewMetrics()}}
+	mirror := gin.New()
+	for i, h := range handlers {
+		i, h := i, h
+		mirror.Use(func(c *gin.Context) {
+			// Đặt lại trước mỗi middleware, vì Core.Attach hay DryRunMiddleware của
+			// engine sẽ ghi đè các giá trị này
+			c.Set(ContextKeyCore, isolated)
+			c.Set(ContextKeySelfTest, true)
+			c.Set(ContextKeyDryRun, true)
+			start := time.Now()
+			h(c)
+			// Chạy phần còn lại nếu middleware không tự gọi c.Next (no-op nếu đã chạy hoặc bị abort)
+			c.Next()
+			inclusive[i] = time.Since(start)
+		})
+	}
+	mirror.GET(selfTestPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, selfTestPath, nil)
+	req.Header.Set("X-Self-Test", "1")
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	mirror.ServeHTTP(rec, req)
+
+	report := SelfTestReport{
+		Status:      rec.Code,
+		Duration:    time.Since(start),
+		Middlewares: make([]MiddlewareTiming, len(handlers)),
+		Issues:      detectStackIssues(names),
+	}
+	for i := range handlers {
+		self := inclusive[i]
+		if i+1 < len(handlers) {
+			self -= inclusive[i+1]
+		}
+		report.Middlewares[i] = MiddlewareTiming{Name: names[i], Inclusive: inclusive[i], Self: max(self, 0)}
+	}
+	if rec.Code != http.StatusOK {
+		report.Issues = append(report.Issues, fmt.Sprintf("synthetic request returned status %d instead of 200", rec.Code))
+	}
+	return report
+}
+
+// selfTestLogger bỏ qua mọi entry của request giả
+type selfTestLogger struct{}
+
+// LogRequest implements Logger interface for selfTestLogger
+func (selfTestLogger) LogRequest(LogEntry) {}
+
+// LogResponse implements Logger interface for selfTestLogger
+func (selfTestLogger) LogResponse(LogEntry) {}
+
+// LogError implements Logger interface for selfTestLogger
+func (selfTestLogger) LogError(string, error) {}
+
+// handlerName trả về tên rút gọn của handler, ví dụ "middleware.LogRequestMiddleware"
+func handlerName(h gin.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	name = funcSuffix.ReplaceAllString(name, "")
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// coreHandlerName là tên handler do Core.Attach thêm vào engine. Handler này chỉ
+// gắn Core vào context và được khuyến nghị cài trước mọi middleware, nên không
+// được tính khi kiểm tra thứ tự.
+const coreHandlerName = "middleware.(*Core).Attach"
+
+// detectStackIssues kiểm tra thứ tự và sự có mặt của các middleware của package
+func detectStackIssues(names []string) []string {
+	issues := []string{}
+	ordered := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != coreHandlerName {
+			ordered = append(ordered, n)
+		}
+	}
+	index := func(name string) int {
+		for i, n := range ordered {
+			if strings.HasPrefix(n, "middleware."+name) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		if seen[n] && strings.HasPrefix(n, "middleware.") {
+			issues = append(issues, fmt.Sprintf("%s is installed more than once", n))
+		}
+		seen[n] = true
+	}
+
+	requestID := index("RequestIDMiddleware")
+	recovery := index("RecoveryMiddleware")
+	logRequest := index("LogRequestMiddleware")
+	logResponse := index("LogResponseMiddleware")
+
+	switch {
+	case recovery < 0:
+		issues = append(issues, "RecoveryMiddleware is not installed: panics will not be logged with a request ID")
+	case recovery > 0 && !(recovery == 1 && requestID == 0):
+		issues = append(issues, "RecoveryMiddleware is not first: panics in earlier middlewares are not recovered")
+	}
+	if logResponse >= 0 && logRequest < 0 {
+		issues = append(issues, "LogResponseMiddleware is installed without LogRequestMiddleware: processing time cannot be measured")
+	}
+	if logResponse >= 0 && logRequest > logResponse {
+		issues = append(issues, "LogResponseMiddleware runs before LogRequestMiddleware: processing time is wrong")
+	}
+	if requestID > 0 && ((logRequest >= 0 && requestID > logRequest) || (recovery >= 0 && requestID > recovery)) {
+		issues = append(issues, "RequestIDMiddleware runs after logging/recovery: incoming request IDs are ignored")
+	}
+	return issues
+}
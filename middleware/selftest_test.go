@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSelfTestRecommendedStackHasNoIssues(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	(&Core{Logger: discardLogger{}, Metrics: NewMetrics()}).Attach(engine, nil)
+	engine.Use(RequestIDMiddleware(), RecoveryMiddleware(), LogRequestMiddleware(), LogResponseMiddleware())
+
+	report := RunSelfTest(engine)
+	if report.Status != 200 || len(report.Issues) != 0 {
+		t.Fatalf("got status %d, issues %q, want 200 and no issues", report.Status, report.Issues)
+	}
+	if name := report.Middlewares[0].Name; name != coreHandlerName {
+		t.Fatalf("first handler: got %q, want %q", name, coreHandlerName)
+	}
+}
+
+func TestSelfTestReportsStackIssues(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack []string
+		want  string
+	}{
+		{"recovery missing", []string{"middleware.LogRequestMiddleware"},
+			"RecoveryMiddleware is not installed: panics will not be logged with a request ID"},
+		{"recovery not first", []string{coreHandlerName, "middleware.LogRequestMiddleware", "middleware.RecoveryMiddleware"},
+			"RecoveryMiddleware is not first: panics in earlier middlewares are not recovered"},
+		{"response before request", []string{"middleware.RecoveryMiddleware", "middleware.LogResponseMiddleware", "middleware.LogRequestMiddleware"},
+			"LogResponseMiddleware runs before LogRequestMiddleware: processing time is wrong"},
+	}
+	for _, tt := range tests {
+		issues := detectStackIssues(tt.stack)
+		if len(issues) != 1 || issues[0] != tt.want {
+			t.Errorf("%s: got %q, want [%q]", tt.name, issues, tt.want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+// systemdListenFDsStart là fd đầu tiên systemd truyền cho process (sd_listen_fds)
+const systemdListenFDsStart = 3
+
+// Các network được Serve hỗ trợ
+const (
+	NetworkTCP     = "tcp"     // Address là địa chỉ host:port
+	NetworkUnix    = "unix"    // Address là đường dẫn unix socket
+	NetworkSystemd = "systemd" // Socket activation, Address là tên socket (FileDescriptorName) hoặc rỗng
+)
+
+// ServeConfig cấu hình cho Serve
+type ServeConfig struct {
+	Network    string      // NetworkTCP (mặc định), NetworkUnix hoặc NetworkSystemd
+	Address    string      // Mặc định ":8080" với NetworkTCP
+	SocketMode os.FileMode // Quyền của unix socket, mặc định 0660
+
+	// TLSCertFile/TLSKeyFile bật HTTPS; certificate được nạp lại khi file thay đổi
+	// (kiểm tra mỗi TLSReloadInterval, mặc định 1 phút) mà không ngắt kết nối
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSReloadInterval time.Duration
+	// ACME, nếu khác nil, bật HTTPS với certificate lấy tự động qua ACME (thay cho
+	// TLSCertFile/TLSKeyFile) và mở thêm listener HTTP cho HTTP-01 challenge;
+	// challenge không đi qua middleware của Handler (xem ACMEConfig)
+	ACME *ACMEConfig
+	// TLSConfig là cấu hình TLS cơ sở (MinVersion, CipherSuites, ...), có thể nil
+	TLSConfig *tls.Config
+
+	ReadHeaderTimeout time.Duration // Mặc định 10 giây
+	IdleTimeout       time.Duration // Mặc định 2 phút
+
+	// DrainDelay là thời gian giữa SetReady(false) và Shutdown, để load balancer
+	// kịp ngừng gửi traffic mới, mặc định 5 giây
+	DrainDelay time.Duration
+	// ShutdownTimeout là thời gian tối đa chờ các request đang xử lý, mặc định 30 giây
+	ShutdownTimeout time.Duration
+	// Signals kích hoạt graceful shutdown, mặc định SIGINT và SIGTERM
+	Signals []os.Signal
+	// Context kích hoạt graceful shutdown khi bị huỷ (ngoài Signals), có thể nil
+	Context context.Context
+
+	// ShutdownReport, nếu khác nil, được dùng để ghi báo cáo cuối (EmitShutdownReport)
+	ShutdownReport *ShutdownReportConfig
+	// OnShutdown chạy theo thứ tự sau khi server đã dừng và báo cáo đã được ghi,
+	// ví dụ đóng AsyncLogger hoặc kết nối database
+	OnShutdown []func(ctx context.Context)
+}
+
+// Validate kiểm tra tính hợp lệ của ServeConfig
+func (o ServeConfig) Validate() error {
+	var errs configErrors
+	switch o.Network {
+	case "", NetworkTCP, NetworkSystemd:
+	case NetworkUnix:
+		if o.Address == "" {
+			errs.addf("Address: socket path is required with network %q", NetworkUnix)
+		}
+	default:
+		errs.addf("Network: unknown network %q", o.Network)
+	}
+	if (o.TLSCertFile == "") != (o.TLSKeyFile == "") {
+		errs.addf("TLSCertFile and TLSKeyFile must be set together")
+	}
+	if o.ACME != nil {
+		if o.TLSCertFile != "" {
+			errs.addf("ACME cannot be combined with TLSCertFile/TLSKeyFile")
+		}
+		if err := o.ACME.Validate(); err != nil {
+			errs.addf("ACME: %v", err)
+		}
+	}
+	if o.TLSReloadInterval < 0 {
+		errs.addf("TLSReloadInterval must not be negative, got %v", o.TLSReloadInterval)
+	}
+	if o.ReadHeaderTimeout < 0 || o.IdleTimeout < 0 || o.DrainDelay < 0 || o.ShutdownTimeout < 0 {
+		errs.addf("ReadHeaderTimeout/IdleTimeout/DrainDelay/ShutdownTimeout must not be negative")
+	}
+	if o.ShutdownReport != nil {
+		if err := o.ShutdownReport.Validate(); err != nil {
+			errs.addf("ShutdownReport: %v", err)
+		}
+	}
+	return errs.err()
+}
+
+// Serve chạy handler (thường là *gin.Engine) trên TCP, unix socket hoặc socket do
+// systemd truyền vào (LISTEN_FDS), và thực hiện graceful drain khi nhận Signals:
+// SetReady(false), chờ DrainDelay, Shutdown chờ request đang xử lý, ghi
+// ShutdownReport rồi chạy OnShutdown. Với TLSCertFile/TLSKeyFile, server phục vụ
+// HTTPS và tự nạp lại certificate khi được xoay vòng; với ACME, certificate được
+// lấy và gia hạn tự động. Trả về nil khi dừng bình thường.
+func Serve(handler http.Handler, config ServeConfig) error {
+	mustValidate("Serve", config)
+	if config.Network == "" {
+		config.Network = NetworkTCP
+	}
+	if config.Network == NetworkTCP && config.Address == "" {
+		config.Address = ":8080"
+	}
+	if config.SocketMode == 0 {
+		config.SocketMode = 0660
+	}
+	if config.ReadHeaderTimeout == 0 {
+		config.ReadHeaderTimeout = 10 * time.Second
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = 2 * time.Minute
+	}
+	if config.DrainDelay == 0 {
+		config.DrainDelay = 5 * time.Second
+	}
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = 30 * time.Second
+	}
+	if len(config.Signals) == 0 {
+		config.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	if config.Context == nil {
+		config.Context = context.Background()
+	}
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+	if config.TLSCertFile != "" {
+		reloader, err := NewCertReloader(config.TLSCertFile, config.TLSKeyFile, config.TLSReloadInterval)
+		if err != nil {
+			return err
+		}
+		defer reloader.Close()
+		server.TLSConfig = serverTLSConfig(config.TLSConfig)
+		server.TLSConfig.GetCertificate = reloader.GetCertificate
+	}
+	servers := []*http.Server{server}
+	var challengeServer *http.Server
+	if config.ACME != nil {
+		acmeConfig := *config.ACME
+		if acmeConfig.HTTPAddress == "" {
+			acmeConfig.HTTPAddress = ":80"
+		}
+		manager := newACMEManager(acmeConfig)
+		server.TLSConfig = serverTLSConfig(config.TLSConfig)
+		server.TLSConfig.GetCertificate = manager.GetCertificate
+		server.TLSConfig.NextProtos = append(server.TLSConfig.NextProtos, "h2", "http/1.1", acme.ALPNProto)
+		challengeServer = &http.Server{
+			Addr:              acmeConfig.HTTPAddress,
+			Handler:           acmeHTTPHandler(manager),
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			IdleTimeout:       config.IdleTimeout,
+		}
+		servers = append(servers, challengeServer)
+	}
+
+	listener, err := Listen(config.Network, config.Address, config.SocketMode)
+	if err != nil {
+		return err
+	}
+
+	serveErr := make(chan error, len(servers))
+	if challengeServer != nil {
+		challengeListener, err := net.Listen("tcp", challengeServer.Addr)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		go func() {
+			serveErr <- challengeServer.Serve(challengeListener)
+		}()
+		logMessage("[SERVER] serving ACME challenges on tcp %s", challengeListener.Addr())
+	}
+	go func() {
+		if server.TLSConfig != nil {
+			serveErr <- server.ServeTLS(listener, "", "")
+			return
+		}
+		serveErr <- server.Serve(listener)
+	}()
+	logMessage("[SERVER] listening on %s %s", config.Network, listener.Addr())
+
+	ctx, stop := signal.NotifyContext(config.Context, config.Signals...)
+	defer stop()
+	select {
+	case err := <-serveErr:
+		for _, s := range servers {
+			_ = s.Close()
+		}
+		return fmt.Errorf("serve: %w", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	return drain(servers, config)
+}
+
+// serverTLSConfig trả về bản sao cấu hình TLS cơ sở, mặc định tối thiểu TLS 1.2
+func serverTLSConfig(base *tls.Config) *tls.Config {
+	if base != nil {
+		return base.Clone()
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12}
+}
+
+// drain thực hiện graceful shutdown của các server theo config
+func drain(servers []*http.Server, config ServeConfig) error {
+	logMessage("[SERVER] shutdown requested, draining for %v", config.DrainDelay)
+	SetReady(false)
+	time.Sleep(config.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	var shutdownErr error
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			shutdownErr = err
+			_ = server.Close()
+		}
+	}
+	if shutdownErr != nil {
+		logMessage("[SERVER] shutdown incomplete: %v", shutdownErr)
+	} else {
+		logMessage("[SERVER] stopped")
+	}
+
+	if config.ShutdownReport != nil {
+		if _, err := EmitShutdownReport(*config.ShutdownReport); err != nil {
+			logMessage("[SERVER] %v", err)
+		}
+	}
+	for _, hook := range config.OnShutdown {
+		hook(ctx)
+	}
+	if shutdownErr != nil {
+		return fmt.Errorf("shutdown: %w", shutdownErr)
+	}
+	return nil
+}
+
+// Listen mở listener cho network (NetworkTCP, NetworkUnix, NetworkSystemd).
+// Với unix socket, file socket cũ còn sót lại được xoá và quyền được đặt theo mode.
+func Listen(network, address string, mode os.FileMode) (net.Listener, error) {
+	switch network {
+	case NetworkTCP:
+		return net.Listen("tcp", address)
+	case NetworkUnix:
+		return listenUnix(address, mode)
+	case NetworkSystemd:
+		return listenSystemd(address)
+	}
+	return nil, fmt.Errorf("listen: unknown network %q", network)
+}
+
+// listenUnix mở unix socket, xoá socket cũ nếu không còn process nào lắng nghe
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("listen unix: %s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("listen unix: %s is in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("listen unix: remove stale socket: %w", err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("listen unix: %w", err)
+	}
+	return listener, nil
+}
+
+// listenSystemd trả về listener từ các fd systemd truyền vào (LISTEN_PID,
+// LISTEN_FDS, LISTEN_FDNAMES). name rỗng chọn fd đầu tiên.
+func listenSystemd(name string) (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("listen systemd: no sockets passed to this process (LISTEN_PID)")
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, errors.New("listen systemd: no sockets passed to this process (LISTEN_FDS)")
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	for i := 0; i < count; i++ {
+		fdName := ""
+		if i < len(names) {
+			fdName = names[i]
+		}
+		if name != "" && fdName != name {
+			continue
+		}
+		fd := systemdListenFDsStart + i
+		file := os.NewFile(uintptr(fd), fdName)
+		listener, err := net.FileListener(file)
+		// FileListener dup fd, file gốc không còn cần thiết
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("listen systemd: fd %d: %w", fd, err)
+		}
+		return listener, nil
+	}
+	return nil, fmt.Errorf("listen systemd: no socket named %q in LISTEN_FDNAMES", name)
+}
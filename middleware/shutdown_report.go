@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// processStartedAt là thời điểm package được khởi tạo, dùng để tính uptime
+var processStartedAt = time.Now()
+
+// ShutdownReportConfig cấu hình cho EmitShutdownReport
+type ShutdownReportConfig struct {
+	TopRoutes int    // Số route nhiều traffic nhất được đưa vào báo cáo, mặc định 10
+	File      string // Nếu khác rỗng, báo cáo JSON được ghi vào file này (ghi đè nội dung cũ)
+}
+
+// Validate kiểm tra tính hợp lệ của ShutdownReportConfig
+func (c ShutdownReportConfig) Validate() error {
+	var errs configErrors
+	if c.TopRoutes < 0 {
+		errs.addf("TopRoutes must not be negative, got %d", c.TopRoutes)
+	}
+	return errs.err()
+}
+
+// RouteHits là số request của một route trong ShutdownReport
+type RouteHits struct {
+	Route string `json:"route"`
+	Hits  uint64 `json:"hits"`
+}
+
+// ShutdownReport là bản tổng kết hoạt động của process khi shutdown
+type ShutdownReport struct {
+	StartedAt     time.Time         `json:"started_at"`
+	StoppedAt     time.Time         `json:"stopped_at"`
+	Uptime        string            `json:"uptime"`
+	TotalRequests uint64            `json:"total_requests"`
+	ClientErrors  uint64            `json:"client_errors"` // Response 4xx
+	ServerErrors  uint64            `json:"server_errors"` // Response 5xx
+	StatusCodes   map[int]uint64    `json:"status_codes"`
+	TopRoutes     []RouteHits       `json:"top_routes"`
+	DroppedLogs   uint64            `json:"dropped_log_entries"` // Theo LoggerStats của logger hiện tại, nếu có
+	Labels        map[string]string `json:"labels,omitempty"`
+	Build         BuildInfo         `json:"build"`
+}
+
+// BuildShutdownReport tổng hợp báo cáo từ metrics hiện tại của package, với tối
+// đa topRoutes route (giá trị âm được coi là 0)
+func BuildShutdownReport(topRoutes int) ShutdownReport {
+	if topRoutes < 0 {
+		topRoutes = 0
+	}
+	now := time.Now()
+	report := ShutdownReport{
+		StartedAt:   processStartedAt,
+		StoppedAt:   now,
+		Uptime:      now.Sub(processStartedAt).Round(time.Second).String(),
+		StatusCodes: make(map[int]uint64),
+		Labels:      StaticLabels(),
+		Build:       ReadBuildInfo(),
+	}
+
+	metrics.mu.RLock()
+	for code, n := range metrics.StatusCodeCounts {
+		report.StatusCodes[code] = n
+		report.TotalRequests += n
+		switch {
+		case code >= 500:
+			report.ServerErrors += n
+		case code >= 400:
+			report.ClientErrors += n
+		}
+	}
+	routes := make([]RouteHits, 0, len(metrics.routeStats))
+	for route, stat := range metrics.routeStats {
+		routes = append(routes, RouteHits{Route: route, Hits: stat.Hits})
+	}
+	metrics.mu.RUnlock()
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Hits != routes[j].Hits {
+			return routes[i].Hits > routes[j].Hits
+		}
+		return routes[i].Route < routes[j].Route
+	})
+	if len(routes) > topRoutes {
+		routes = routes[:topRoutes]
+	}
+	report.TopRoutes = routes
+
+	if s, ok := defaultLogger.(LoggerStats); ok {
+		report.DroppedLogs = droppedLogEntries(s.LoggerStats())
+	}
+	return report
+}
+
+// droppedLogEntries cộng số entry bị bỏ của logger và các backend lồng nhau
+// (ví dụ AsyncLogger bọc ResilientLogger)
+func droppedLogEntries(stats map[string]interface{}) uint64 {
+	var total uint64
+	if n, ok := stats["dropped"].(uint64); ok {
+		total += n
+	}
+	if backend, ok := stats["backend"].(map[string]interface{}); ok {
+		total += droppedLogEntries(backend)
+	}
+	return total
+}
+
+// EmitShutdownReport tổng hợp báo cáo cuối cùng, ghi một dòng "[SHUTDOWN] {json}"
+// qua Logger và ghi file JSON nếu File được cấu hình (file chỉ giữ báo cáo của lần
+// shutdown gần nhất; bản của mọi lần deploy nằm trong log). Gọi sau khi server đã ngừng nhận request nhưng trước khi đóng
+// logger (ví dụ AsyncLogger.Close), nếu không dòng log sẽ bị bỏ.
+func EmitShutdownReport(config ShutdownReportConfig) (ShutdownReport, error) {
+	mustValidate("ShutdownReport", config)
+	if config.TopRoutes == 0 {
+		config.TopRoutes = 10
+	}
+
+	report := BuildShutdownReport(config.TopRoutes)
+	data, err := json.Marshal(report)
+	if err != nil {
+		return report, fmt.Errorf("encode shutdown report: %w", err)
+	}
+	logMessage("[SHUTDOWN] %s", data)
+
+	if config.File != "" {
+		indented, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(config.File, append(indented, '\n'), 0644); err != nil {
+			return report, fmt.Errorf("write shutdown report: %w", err)
+		}
+	}
+	return report, nil
+}
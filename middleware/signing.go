@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseSigner ký body của response
+type ResponseSigner interface {
+	// Algorithm trả về tên thuật toán, được gửi kèm trong header
+	Algorithm() string
+	// Sign trả về chữ ký của body
+	Sign(body []byte) ([]byte, error)
+}
+
+// HMACSigner ký response bằng HMAC-SHA256 với khoá bí mật dùng chung
+type HMACSigner struct {
+	key []byte
+}
+
+// NewHMACSigner tạo HMACSigner với khoá bí mật
+func NewHMACSigner(key []byte) *HMACSigner {
+	return &HMACSigner{key: key}
+}
+
+// Algorithm implements ResponseSigner
+func (s *HMACSigner) Algorithm() string {
+	return "hmac-sha256"
+}
+
+// Sign implements ResponseSigner
+func (s *HMACSigner) Sign(body []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(body)
+	return mac.Sum(nil), nil
+}
+
+// Verify kiểm tra chữ ký HMAC của body
+func (s *HMACSigner) Verify(body, signature []byte) bool {
+	expected, _ := s.Sign(body)
+	return hmac.Equal(expected, signature)
+}
+
+// Ed25519Signer ký response bằng Ed25519, client chỉ cần public key để kiểm tra
+type Ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewEd25519Signer tạo Ed25519Signer với private key
+func NewEd25519Signer(key ed25519.PrivateKey) *Ed25519Signer {
+	return &Ed25519Signer{key: key}
+}
+
+// Algorithm implements ResponseSigner
+func (s *Ed25519Signer) Algorithm() string {
+	return "ed25519"
+}
+
+// Sign implements ResponseSigner
+func (s *Ed25519Signer) Sign(body []byte) ([]byte, error) {
+	if len(s.key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key size: %d", len(s.key))
+	}
+	return ed25519.Sign(s.key, body), nil
+}
+
+// ResponseSigningConfig cấu hình cho ResponseSigningMiddleware
+type ResponseSigningConfig struct {
+	Signer          ResponseSigner // Bộ ký, bắt buộc
+	KeyID           string         // ID của khoá, gửi trong header nếu khác rỗng
+	SignatureHeader string         // Mặc định "X-Signature"
+	AlgorithmHeader string         // Mặc định "X-Signature-Algorithm"
+	KeyIDHeader     string         // Mặc định "X-Signature-Key-Id"
+}
+
+// Validate kiểm tra tính hợp lệ của ResponseSigningConfig
+func (c ResponseSigningConfig) Validate() error {
+	var errs configErrors
+	if c.Signer == nil {
+		errs.addf("Signer is required")
+	}
+	errs.checkHeaderName("SignatureHeader", c.SignatureHeader)
+	errs.checkHeaderName("AlgorithmHeader", c.AlgorithmHeader)
+	errs.checkHeaderName("KeyIDHeader", c.KeyIDHeader)
+	if c.SignatureHeader != "" && (strings.EqualFold(c.SignatureHeader, c.AlgorithmHeader) || strings.EqualFold(c.SignatureHeader, c.KeyIDHeader)) {
+		errs.addf("SignatureHeader %q overlaps with another signing header", c.SignatureHeader)
+	}
+	return errs.err()
+}
+
+// ResponseSigningMiddleware trả về middleware ký body của response.
+// Body được buffer toàn bộ, chữ ký (base64) được gửi trong header
+// để client kiểm tra payload không bị thay đổi bởi các proxy trung gian.
+// Nếu ký thất bại, lỗi được log và response được gửi đi không có chữ ký.
+func ResponseSigningMiddleware(config ResponseSigningConfig) gin.HandlerFunc {
+	mustValidate("ResponseSigning", config)
+	if config.SignatureHeader == "" {
+		config.SignatureHeader = "X-Signature"
+	}
+	if config.AlgorithmHeader == "" {
+		config.AlgorithmHeader = "X-Signature-Algorithm"
+	}
+	if config.KeyIDHeader == "" {
+		config.KeyIDHeader = "X-Signature-Key-Id"
+	}
+
+	return func(c *gin.Context) {
+		writer := newBufferedWriter(c.Writer)
+		c.Writer = writer
+		defer func() {
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+
+		signature, err := config.Signer.Sign(writer.body.Bytes())
+		if err != nil {
+			loggerOf(c).LogError(RequestID(c), fmt.Errorf("sign response: %w", err))
+		} else {
+			header := writer.Header()
+			header.Set(config.SignatureHeader, base64.StdEncoding.EncodeToString(signature))
+			header.Set(config.AlgorithmHeader, config.Signer.Algorithm())
+			if config.KeyID != "" {
+				header.Set(config.KeyIDHeader, config.KeyID)
+			}
+		}
+		writer.flush()
+	}
+}
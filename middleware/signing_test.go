@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// signedRouter trả về router ký response của GET /orders
+func signedRouter(config ResponseSigningConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	core := &Core{Logger: discardLogger{}, Metrics: NewMetrics()}
+	r := gin.New()
+	core.Attach(r, nil)
+	r.Use(ResponseSigningMiddleware(config))
+	r.GET("/orders", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"id": 1}) })
+	return r
+}
+
+func TestResponseSigningHMAC(t *testing.T) {
+	signer := NewHMACSigner([]byte("secret"))
+	w := httptest.NewRecorder()
+	signedRouter(ResponseSigningConfig{Signer: signer, KeyID: "k1"}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"id":1}` {
+		t.Fatalf("response: got %d %q, want 200 {\"id\":1}", w.Code, w.Body.String())
+	}
+	signature, err := base64.StdEncoding.DecodeString(w.Header().Get("X-Signature"))
+	if err != nil || !signer.Verify(w.Body.Bytes(), signature) {
+		t.Fatalf("X-Signature %q does not verify the body", w.Header().Get("X-Signature"))
+	}
+	if signer.Verify([]byte(`{"id":2}`), signature) {
+		t.Fatalf("signature verifies a modified body")
+	}
+	if got := w.Header().Get("X-Signature-Algorithm"); got != "hmac-sha256" {
+		t.Fatalf("X-Signature-Algorithm: got %q, want hmac-sha256", got)
+	}
+	if got := w.Header().Get("X-Signature-Key-Id"); got != "k1" {
+		t.Fatalf("X-Signature-Key-Id: got %q, want k1", got)
+	}
+}
+
+func TestResponseSigningEd25519(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	signedRouter(ResponseSigningConfig{Signer: NewEd25519Signer(private), SignatureHeader: "X-Body-Signature"}).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	signature, err := base64.StdEncoding.DecodeString(w.Header().Get("X-Body-Signature"))
+	if err != nil || !ed25519.Verify(public, w.Body.Bytes(), signature) {
+		t.Fatalf("X-Body-Signature %q does not verify with the public key", w.Header().Get("X-Body-Signature"))
+	}
+	if got := w.Header().Get("X-Signature-Key-Id"); got != "" {
+		t.Fatalf("X-Signature-Key-Id without KeyID: got %q, want empty", got)
+	}
+}
+
+func TestResponseSigningFailureSendsUnsignedResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	signedRouter(ResponseSigningConfig{Signer: NewEd25519Signer(ed25519.PrivateKey("short"))}).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"id":1}` {
+		t.Fatalf("response: got %d %q, want 200 {\"id\":1}", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Signature"); got != "" {
+		t.Fatalf("X-Signature after a signing failure: got %q, want empty", got)
+	}
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tx là transaction tối thiểu mà TransactionMiddleware cần (*sql.Tx thoả mãn trực tiếp)
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// TxStarter mở transaction mới. Với pgx hoặc gorm, viết adapter nhỏ bằng
+// TxStarterFunc, ví dụ bọc pgx.Tx để Commit() gọi tx.Commit(ctx).
+type TxStarter interface {
+	BeginTx(ctx context.Context) (Tx, error)
+}
+
+// TxStarterFunc là adapter cho phép dùng function làm TxStarter
+type TxStarterFunc func(ctx context.Context) (Tx, error)
+
+// BeginTx implements TxStarter
+func (f TxStarterFunc) BeginTx(ctx context.Context) (Tx, error) {
+	return f(ctx)
+}
+
+// SQLTxStarter tạo TxStarter từ *sql.DB
+func SQLTxStarter(db *sql.DB, opts *sql.TxOptions) TxStarter {
+	return TxStarterFunc(func(ctx context.Context) (Tx, error) {
+		return db.BeginTx(ctx, opts)
+	})
+}
+
+// Transaction trả về transaction của request hiện tại, nil nếu không có.
+// Với *sql.DB có thể ép kiểu: middleware.Transaction(c).(*sql.Tx)
+func Transaction(c *gin.Context) Tx {
+	if v, ok := c.Get(ContextKeyTx); ok {
+		if tx, ok := v.(Tx); ok {
+			return tx
+		}
+	}
+	return nil
+}
+
+// TransactionMiddleware trả về middleware mở transaction cho mỗi request và lưu
+// vào context (đọc qua Transaction). Transaction được commit khi response là 2xx
+// và không có c.Errors, ngược lại (lỗi, panic, 4xx/5xx, dry-run) thì rollback.
+// Response được giữ lại cho tới khi commit xong, nên nếu commit lỗi client nhận
+// 500 thay vì một response thành công giả; hook AfterSuccess đăng ký bên trong
+// cũng chỉ chạy khi commit thành công, và bản ghi của AuditMiddleware bên trong
+// chỉ được ghi khi đó. Header do handler đặt không xuất hiện trong response 500
+// đó. Thời gian và kết quả được ghi vào metrics (key "transactions"), commit lỗi
+// được đếm riêng dưới "commit_failures".
+//
+// Panic nếu starter là nil.
+func TransactionMiddleware(starter TxStarter) gin.HandlerFunc {
+	mustValidate("Transaction", validatorFunc(func() error {
+		if starter == nil {
+			return errors.New("TxStarter is required")
+		}
+		return nil
+	}))
+
+	return func(c *gin.Context) {
+		requestID := ensureRequestID(c)
+
+		tx, err := starter.BeginTx(c.Request.Context())
+		if err != nil {
+			atomic.AddUint64(&metricsOf(c).TxFailures, 1)
+			loggerOf(c).LogError(requestID, fmt.Errorf("begin transaction: %w", err))
+			AbortWithReason(c, 500, "transaction", "begin transaction failed", gin.H{
+				"message":    Message(c, MessageInternalError),
+				"request_id": requestID,
+			})
+			return
+		}
+		c.Set(ContextKeyTx, tx)
+		start := time.Now()
+		// Header trước khi handler chạy, để response 500 khi commit lỗi chỉ giữ
+		// header của các middleware bên ngoài (request ID, rate limit, ...)
+		header := c.Writer.Header().Clone()
+
+		writer := newBufferedWriter(c.Writer)
+		c.Writer = writer
+
+		defer func() {
+			if r := recover(); r != nil {
+				c.Writer = writer.ResponseWriter
+				rollbackTx(c, requestID, tx)
+				metricsOf(c).RecordTransaction(false, time.Since(start))
+				skipHeldAfterSuccessHooks(c)
+				panic(r)
+			}
+		}()
+
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		status := writer.Status()
+		if status < 200 || status >= 300 || len(c.Errors) > 0 || IsDryRun(c) {
+			rollbackTx(c, requestID, tx)
+			metricsOf(c).RecordTransaction(false, time.Since(start))
+			skipHeldAfterSuccessHooks(c)
+			writer.flush()
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			m := metricsOf(c)
+			atomic.AddUint64(&m.TxFailures, 1)
+			atomic.AddUint64(&m.TxCommitFailures, 1)
+			atomic.AddUint64(&m.TxDuration, uint64(time.Since(start).Milliseconds()))
+			loggerOf(c).LogError(requestID, fmt.Errorf("commit transaction: %w", err))
+			skipHeldAfterSuccessHooks(c)
+			resetHeader(c.Writer.Header(), header)
+			c.JSON(500, gin.H{
+				"message":    Message(c, MessageInternalError),
+				"request_id": requestID,
+			})
+			return
+		}
+		metricsOf(c).RecordTransaction(true, time.Since(start))
+		writeHeldAudit(c)
+		writer.flush()
+		if hooks := takeHeldAfterSuccessHooks(c); len(hooks) > 0 {
+			startAfterSuccessHooks(c, hooks)
+		}
+	}
+}
+
+// resetHeader đưa header về snapshot trước đó
+func resetHeader(header, snapshot http.Header) {
+	for k := range header {
+		delete(header, k)
+	}
+	for k, v := range snapshot {
+		header[k] = v
+	}
+}
+
+// rollbackTx rollback transaction và log lỗi nếu có
+func rollbackTx(c *gin.Context, requestID string, tx Tx) {
+	if err := tx.Rollback(); err != nil {
+		atomic.AddUint64(&metricsOf(c).TxFailures, 1)
+		loggerOf(c).LogError(requestID, fmt.Errorf("rollback transaction: %w", err))
+	}
+}
+
+// RecordTransaction records the outcome and duration of a request transaction
+func (m *Metrics) RecordTransaction(committed bool, duration time.Duration) {
+	if committed {
+		atomic.AddUint64(&m.TxCommits, 1)
+	} else {
+		atomic.AddUint64(&m.TxRollbacks, 1)
+	}
+	atomic.AddUint64(&m.TxDuration, uint64(duration.Milliseconds()))
+}
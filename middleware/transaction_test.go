@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTransactionCommitFailureDropsHandlerHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core := &Core{Logger: discardLogger{}, Metrics: NewMetrics()}
+	tx := &fakeTx{commitErr: errors.New("connection reset")}
+
+	r := gin.New()
+	core.Attach(r, nil)
+	r.Use(func(c *gin.Context) {
+		c.Header("X-Outer", "kept")
+		c.Next()
+	})
+	r.Use(TransactionMiddleware(TxStarterFunc(func(context.Context) (Tx, error) { return tx, nil })))
+	r.POST("/orders", func(c *gin.Context) {
+		c.Header("Location", "/orders/42")
+		c.Data(http.StatusCreated, "text/csv", []byte("id\n42\n"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("got %d, want 500", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "" {
+		t.Fatalf("Location: got %q, want the handler header dropped", loc)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Fatalf("Content-Type: got %q, want JSON error body", ct)
+	}
+	if w.Header().Get("X-Outer") != "kept" {
+		t.Fatal("X-Outer: want headers of outer middlewares kept")
+	}
+
+	m := core.Metrics
+	if atomic.LoadUint64(&m.TxCommitFailures) != 1 || atomic.LoadUint64(&m.TxRollbacks) != 0 || atomic.LoadUint64(&m.TxCommits) != 0 {
+		t.Fatalf("metrics: got commit failures %d, rollbacks %d, commits %d, want 1, 0, 0",
+			m.TxCommitFailures, m.TxRollbacks, m.TxCommits)
+	}
+}
+
+func TestTransactionMiddlewareRequiresStarter(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("want panic for a nil TxStarter")
+		}
+	}()
+	TransactionMiddleware(nil)
+}
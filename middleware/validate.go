@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validator được implement bởi các config có thể tự kiểm tra tính hợp lệ
+type Validator interface {
+	Validate() error
+}
+
+// mustValidate kiểm tra config khi khởi tạo middleware và panic với lỗi mô tả
+// rõ ràng nếu config không hợp lệ, để lỗi cấu hình lộ ra ngay khi khởi động
+// thay vì âm thầm chạy sai lúc runtime (giống cách gin panic khi đăng ký route sai).
+func mustValidate(name string, config Validator) {
+	if err := config.Validate(); err != nil {
+		panic(fmt.Sprintf("middleware: invalid %s config: %v", name, err))
+	}
+}
+
+// validatorFunc là adapter để kiểm tra tham số của constructor không nhận struct
+// config (ví dụ TransactionMiddleware(starter)) bằng mustValidate
+type validatorFunc func() error
+
+// Validate implements Validator
+func (f validatorFunc) Validate() error {
+	return f()
+}
+
+// configErrors gom nhiều lỗi cấu hình thành một error
+type configErrors []error
+
+// addf thêm một lỗi định dạng theo field
+func (e *configErrors) addf(format string, args ...interface{}) {
+	*e = append(*e, fmt.Errorf(format, args...))
+}
+
+// err trả về error tổng hợp, nil nếu không có lỗi
+func (e configErrors) err() error {
+	return errors.Join(e...)
+}
+
+// checkExactPaths kiểm tra danh sách path so khớp chính xác (không theo prefix):
+// bắt đầu bằng "/" và không trùng nhau, vì với so khớp chính xác hai path chỉ
+// chồng lấn khi chúng giống hệt nhau
+func (e *configErrors) checkExactPaths(field string, paths []string) {
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		if !strings.HasPrefix(p, "/") {
+			e.addf("%s: path %q must start with \"/\"", field, p)
+		}
+		if seen[p] {
+			e.addf("%s: path %q is listed more than once", field, p)
+		}
+		seen[p] = true
+	}
+}
+
+// checkHeaderName kiểm tra tên header (nếu có) chỉ gồm ký tự token hợp lệ của HTTP
+func (e *configErrors) checkHeaderName(field, name string) {
+	for i := 0; i < len(name); i++ {
+		if name[i] <= ' ' || name[i] >= 0x7f || strings.IndexByte("()<>@,;:\\\"/[]?={}", name[i]) >= 0 {
+			e.addf("%s: %q is not a valid header name", field, name)
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kimxuanhong/go-middleware/store"
+)
+
+// mustPanic chạy f và trả về thông điệp panic, fail nếu f không panic
+func mustPanic(t *testing.T, name string, f func()) (message string) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Errorf("%s: want panic", name)
+			return
+		}
+		message, _ = r.(string)
+	}()
+	f()
+	return ""
+}
+
+func TestConstructorsRejectInvalidArguments(t *testing.T) {
+	s := store.NewMemoryStore(store.MemoryConfig{})
+	defer s.Close()
+
+	tests := []struct {
+		name string
+		want string
+		f    func()
+	}{
+		{"store rate limiter without store", "Store is required",
+			func() { NewStoreRateLimiter(nil, 10, time.Second) }},
+		{"store rate limiter with zero limit", "limit must be positive",
+			func() { NewStoreRateLimiter(s, 0, time.Second) }},
+		{"store rate limiter with zero window", "window must be positive",
+			func() { NewStoreRateLimiter(s, 10, 0) }},
+		{"redis rate limiter without client", "RedisClient is required",
+			func() { NewRedisRateLimiter(nil, 10, time.Second) }},
+		{"audit with nil sink func", "AuditSinkFunc is nil",
+			func() { AuditMiddleware(AuditConfig{Sink: AuditSinkFunc(nil)}) }},
+		{"client version with invalid header", "is not a valid header name",
+			func() { ClientVersionMiddleware("X Client Version") }},
+		{"dependency check without name", "Name is required",
+			func() { RegisterDependencyCheck(DependencyCheck{Check: func(context.Context) error { return nil }}) }},
+		{"dependency check without check", "Check is required",
+			func() { RegisterDependencyCheck(DependencyCheck{Name: "db"}) }},
+		{"log sampling rate above 1", "SampleRate must be within (0, 1]",
+			func() { LogSamplingMiddleware(LogSamplingConfig{SampleRate: 1.5}) }},
+	}
+	for _, tt := range tests {
+		if msg := mustPanic(t, tt.name, tt.f); msg != "" && !strings.Contains(msg, tt.want) {
+			t.Errorf("%s: got panic %q, want it to mention %q", tt.name, msg, tt.want)
+		}
+	}
+}
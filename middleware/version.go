@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// modulePath là module path của package này, dùng để tìm version trong build info
+const modulePath = "github.com/kimxuanhong/go-middleware"
+
+// BuildInfo là thông tin build của binary đang chạy
+type BuildInfo struct {
+	Path              string `json:"path"`                         // Module path của binary
+	Version           string `json:"version"`                      // Version của main module
+	Revision          string `json:"revision,omitempty"`           // Build SHA (vcs.revision)
+	BuildTime         string `json:"build_time,omitempty"`         // Thời điểm commit (vcs.time)
+	Modified          bool   `json:"modified"`                     // Working tree có thay đổi chưa commit
+	GoVersion         string `json:"go_version"`                   // Version Go dùng để build
+	MiddlewareVersion string `json:"middleware_version,omitempty"` // Version của package middleware
+}
+
+var (
+	buildInfoOnce sync.Once
+	buildInfo     BuildInfo
+)
+
+// ReadBuildInfo trả về thông tin build đọc từ debug.ReadBuildInfo (được cache)
+func ReadBuildInfo() BuildInfo {
+	buildInfoOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		buildInfo = BuildInfo{
+			Path:      info.Main.Path,
+			Version:   info.Main.Version,
+			GoVersion: info.GoVersion,
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				buildInfo.Revision = s.Value
+			case "vcs.time":
+				buildInfo.BuildTime = s.Value
+			case "vcs.modified":
+				buildInfo.Modified = s.Value == "true"
+			}
+		}
+		if info.Main.Path == modulePath {
+			buildInfo.MiddlewareVersion = info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				buildInfo.MiddlewareVersion = dep.Version
+				if dep.Replace != nil {
+					buildInfo.MiddlewareVersion = dep.Replace.Version
+				}
+			}
+		}
+	})
+	return buildInfo
+}
+
+// StampBuildInfoLabels thêm thông tin build (build_sha, version, go_version)
+// vào bộ label tĩnh để xuất hiện trong mọi log entry và metrics
+func StampBuildInfoLabels() {
+	info := ReadBuildInfo()
+	labels := map[string]string{"go_version": info.GoVersion}
+	if info.Revision != "" {
+		labels["build_sha"] = info.Revision
+	}
+	if info.Version != "" {
+		labels["version"] = info.Version
+	}
+	AddStaticLabels(labels)
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WarmupTaskStatus là trạng thái của một hàm warm-up
+type WarmupTaskStatus struct {
+	Name     string        `json:"name"`
+	Done     bool          `json:"done"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// WarmupReport là trạng thái tổng hợp của quá trình warm-up
+type WarmupReport struct {
+	Started  bool               `json:"started"`
+	Complete bool               `json:"complete"` // Mọi hàm đã chạy xong, kể cả hàm lỗi
+	Failed   int                `json:"failed"`   // Số hàm trả về lỗi hoặc panic
+	Tasks    []WarmupTaskStatus `json:"tasks"`
+}
+
+// warmupTask là một hàm warm-up đã đăng ký
+type warmupTask struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+var warmup struct {
+	mu       sync.Mutex
+	tasks    []warmupTask
+	statuses []WarmupTaskStatus
+	started  bool
+	complete bool
+	done     chan struct{}
+}
+
+// RegisterWarmup đăng ký hàm warm-up (prime cache, gọi thử các route, ...).
+// Khi có hàm warm-up, readiness là not ready cho tới khi mọi hàm đã chạy xong.
+// Warm-up được khởi chạy bởi RunWarmups (nên gọi lúc khởi động, sau khi đăng ký
+// xong), hoặc tự động ở lần gọi đầu tiên của readiness endpoint hay endpoint
+// warm-up, nên service không bị kẹt ở not ready khi quên gọi RunWarmups.
+//
+// Warm-up là best effort: hàm lỗi được log và đếm trong WarmupReport.Failed nhưng
+// vẫn tính là đã chạy xong, vì service vẫn phục vụ được (chỉ chậm hơn) và không có
+// cơ chế chạy lại. Điều kiện bắt buộc để nhận traffic nên dùng RegisterDependencyCheck.
+//
+// Panic nếu warm-up đã được khởi chạy (RunWarmups hoặc endpoint warm-up), vì
+// hàm đăng ký muộn sẽ không bao giờ chạy mà readiness vẫn báo hoàn tất.
+func RegisterWarmup(name string, fn func(ctx context.Context) error) {
+	warmup.mu.Lock()
+	defer warmup.mu.Unlock()
+	if warmup.started {
+		panic(fmt.Sprintf("middleware: RegisterWarmup(%q) called after warm-up started", name))
+	}
+	warmup.tasks = append(warmup.tasks, warmupTask{name: name, fn: fn})
+}
+
+// RunWarmups chạy tuần tự các hàm warm-up đã đăng ký (chỉ một lần) và chờ tới
+// khi hoàn tất hoặc ctx bị huỷ. ctx được truyền cho từng hàm, nên huỷ ctx cũng
+// dừng các hàm chưa xong. Lỗi của từng hàm được log và ghi trong báo cáo,
+// không chặn các hàm còn lại.
+func RunWarmups(ctx context.Context) WarmupReport {
+	done := startWarmups(ctx)
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	return WarmupStatus()
+}
+
+// startWarmups khởi chạy warm-up với ctx trong goroutine riêng nếu chưa chạy,
+// trả về channel được đóng khi hoàn tất. Khi chưa có hàm nào được đăng ký thì
+// không có gì để chạy và warm-up không bị coi là đã khởi chạy, để readiness
+// probe đến sớm không chặn RegisterWarmup về sau.
+func startWarmups(ctx context.Context) <-chan struct{} {
+	warmup.mu.Lock()
+	defer warmup.mu.Unlock()
+	if warmup.started {
+		return warmup.done
+	}
+	if len(warmup.tasks) == 0 {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+
+	warmup.started = true
+	warmup.done = make(chan struct{})
+	tasks := append([]warmupTask(nil), warmup.tasks...)
+	warmup.statuses = make([]WarmupTaskStatus, len(tasks))
+	for i, t := range tasks {
+		warmup.statuses[i] = WarmupTaskStatus{Name: t.name}
+	}
+
+	go func() {
+		for i, t := range tasks {
+			start := time.Now()
+			err := runWarmupTask(ctx, t)
+
+			warmup.mu.Lock()
+			warmup.statuses[i].Done = true
+			warmup.statuses[i].Duration = time.Since(start)
+			if err != nil {
+				warmup.statuses[i].Error = err.Error()
+			}
+			warmup.mu.Unlock()
+
+			if err != nil {
+				defaultLogger.LogError("", fmt.Errorf("warmup %s: %w", t.name, err))
+			}
+		}
+
+		warmup.mu.Lock()
+		warmup.complete = true
+		close(warmup.done)
+		warmup.mu.Unlock()
+		logMessage("[WARMUP] completed %d warm-up tasks", len(tasks))
+	}()
+	return warmup.done
+}
+
+// runWarmupTask chạy một hàm warm-up, chuyển panic thành lỗi
+func runWarmupTask(ctx context.Context, t warmupTask) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return t.fn(ctx)
+}
+
+// WarmupStatus trả về trạng thái warm-up hiện tại
+func WarmupStatus() WarmupReport {
+	warmup.mu.Lock()
+	defer warmup.mu.Unlock()
+	report := WarmupReport{
+		Started:  warmup.started,
+		Complete: warmup.complete || len(warmup.tasks) == 0,
+		Tasks:    append([]WarmupTaskStatus{}, warmup.statuses...),
+	}
+	for _, t := range report.Tasks {
+		if t.Error != "" {
+			report.Failed++
+		}
+	}
+	return report
+}
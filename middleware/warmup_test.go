@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resetWarmups xoá trạng thái warm-up toàn cục giữa các test
+func resetWarmups(t *testing.T) {
+	t.Helper()
+	warmup.mu.Lock()
+	defer warmup.mu.Unlock()
+	warmup.tasks, warmup.statuses = nil, nil
+	warmup.started, warmup.complete, warmup.done = false, false, nil
+}
+
+// readyBody là body của readiness endpoint
+type readyBody struct {
+	Status string       `json:"status"`
+	Warmup WarmupReport `json:"warmup"`
+}
+
+func TestReadinessStartsWarmupsAndReportsFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resetWarmups(t)
+	defer resetWarmups(t)
+
+	release := make(chan struct{})
+	RegisterWarmup("cache", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	RegisterWarmup("templates", func(context.Context) error { return errors.New("missing template") })
+
+	r := gin.New()
+	RegisterOpsEndpoints(r, OpsConfig{})
+	ready := func() (int, readyBody) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var body readyBody
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, body
+	}
+
+	// Lần probe đầu tiên khởi chạy warm-up dù RunWarmups chưa được gọi
+	code, body := ready()
+	if code != http.StatusServiceUnavailable || !body.Warmup.Started || body.Warmup.Complete {
+		t.Fatalf("while warming: got %d %+v, want 503 with warm-up started", code, body)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for !WarmupStatus().Complete && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// Hàm lỗi vẫn tính là hoàn tất, chỉ được đếm trong Failed
+	code, body = ready()
+	if code != http.StatusOK || body.Status != "ready" || body.Warmup.Failed != 1 {
+		t.Fatalf("after warm-up: got %d %+v, want 200 ready with 1 failed task", code, body)
+	}
+}
+
+func TestReadinessWithoutWarmupsDoesNotBlockRegistration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resetWarmups(t)
+	defer resetWarmups(t)
+
+	r := gin.New()
+	RegisterOpsEndpoints(r, OpsConfig{})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", w.Code)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("RegisterWarmup after an early probe panicked: %v", r)
+		}
+	}()
+	RegisterWarmup("late", func(context.Context) error { return nil })
+}
@@ -0,0 +1,521 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Webhook events sent by WebhookNotifier
+const (
+	WebhookEventPanic           = "panic"
+	WebhookEventThresholdBreach = "threshold_breach"
+)
+
+// Headers of a webhook delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" so receivers can reject replayed deliveries.
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+)
+
+// maxWebhookRetryAfter caps the Retry-After delay honoured between two attempts,
+// so an endpoint asking for hours does not stall its URL's queue until Close
+const maxWebhookRetryAfter = time.Minute
+
+// webhookMetricsExcerpt lists the GetMetrics keys copied into each payload
+var webhookMetricsExcerpt = []string{"total_requests", "status_code_counts", "average_duration_ms", "client_disconnects", "labels"}
+
+// WebhookPayload is the JSON body POSTed by WebhookNotifier
+type WebhookPayload struct {
+	Event   string                 `json:"event"`
+	Time    time.Time              `json:"time"`
+	Entry   *LogEntry              `json:"entry,omitempty"`
+	Stack   string                 `json:"stack,omitempty"`
+	Breach  *WebhookBreach         `json:"breach,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	Metrics map[string]interface{} `json:"metrics"`
+}
+
+// WebhookBreach describes the threshold that triggered a threshold_breach event
+type WebhookBreach struct {
+	Threshold    string  `json:"threshold"` // "server_errors" or "error_rate"
+	Value        float64 `json:"value"`
+	Limit        float64 `json:"limit"`
+	Window       string  `json:"window"`
+	Requests     uint64  `json:"requests"`
+	ServerErrors uint64  `json:"server_errors"`
+}
+
+// WebhookThresholds configures threshold_breach events, evaluated over each Window
+type WebhookThresholds struct {
+	Window          time.Duration // Evaluation window, default 1m
+	MaxServerErrors uint64        // 5xx responses per window that trigger an event, 0 disables
+	MaxErrorRate    float64       // Share of 5xx responses per window (0-1) that triggers an event, 0 disables
+	MinRequests     uint64        // Requests needed in a window before MaxErrorRate applies, default 20
+	Cooldown        time.Duration // Min time between two events of the same threshold, default 10m
+}
+
+// WebhookConfig configures NewWebhookNotifier
+type WebhookConfig struct {
+	URLs       []string          // Endpoints receiving every event, required
+	Secret     []byte            // HMAC-SHA256 key signing each delivery, unsigned when empty
+	Client     *http.Client      // HTTP client, default http.DefaultClient
+	Timeout    time.Duration     // Max time of one attempt, default 5s
+	MaxRetries int               // Retries after a failed attempt, default 3
+	Backoff    time.Duration     // Delay before the first retry, doubled for each next one, default 1s
+	QueueSize  int               // Events waiting for delivery to each URL, default 64; newer events are dropped when full
+	Thresholds WebhookThresholds // Threshold breaches to report, none when zero
+	Metrics    *Metrics          // Source of the metrics excerpt, default GetMetrics()
+}
+
+// Validate checks the WebhookConfig for invalid values
+func (c WebhookConfig) Validate() error {
+	var errs configErrors
+	if len(c.URLs) == 0 {
+		errs.addf("URLs is required")
+	}
+	for _, url := range c.URLs {
+		if _, err := http.NewRequest(http.MethodPost, url, nil); err != nil {
+			errs.addf("URLs: %v", err)
+		}
+	}
+	if c.Timeout < 0 || c.Backoff < 0 || c.Thresholds.Window < 0 || c.Thresholds.Cooldown < 0 {
+		errs.addf("Timeout/Backoff/Thresholds.Window/Thresholds.Cooldown must not be negative")
+	}
+	if c.MaxRetries < 0 || c.QueueSize < 0 {
+		errs.addf("MaxRetries/QueueSize must not be negative")
+	}
+	if c.Thresholds.MaxErrorRate < 0 || c.Thresholds.MaxErrorRate > 1 {
+		errs.addf("Thresholds.MaxErrorRate must be between 0 and 1, got %v", c.Thresholds.MaxErrorRate)
+	}
+	return errs.err()
+}
+
+// WebhookNotifier wraps a Logger and POSTs a JSON payload (entry, stack and a
+// metrics excerpt) to every configured URL when a request panics (LogError with
+// a *PanicError) or a threshold is breached; Notify sends custom events, e.g.
+// from GoroutineWatchdogConfig.OnLeak. Each URL has its own queue and worker,
+// so a slow or failing endpoint does not hold up the others; failed attempts are
+// retried with exponential backoff on network errors, 429 and 5xx, waiting for
+// Retry-After (capped at 1m) instead when the endpoint sends one. Call Close on shutdown to stop
+// threshold evaluation and flush queued events.
+type WebhookNotifier struct {
+	backend Logger
+	config  WebhookConfig
+	queue   chan WebhookPayload
+	targets []*webhookTarget
+
+	requests     atomic.Uint64
+	serverErrors atomic.Uint64
+	lastBreach   map[string]time.Time
+
+	sent    atomic.Uint64
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+
+	closed    atomic.Bool
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+
+	// ctx is cancelled when Close gives up, aborting in-flight attempts and backoff waits
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// webhookTarget is one URL with its own delivery queue
+type webhookTarget struct {
+	url   string
+	queue chan webhookDelivery
+}
+
+// webhookDelivery is an encoded event waiting for delivery to a target
+type webhookDelivery struct {
+	event string
+	body  []byte
+}
+
+// NewWebhookNotifier wraps backend (which may be nil to only send webhooks)
+func NewWebhookNotifier(backend Logger, config WebhookConfig) *WebhookNotifier {
+	mustValidate("Webhook", config)
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.Backoff == 0 {
+		config.Backoff = time.Second
+	}
+	if config.QueueSize == 0 {
+		config.QueueSize = 64
+	}
+	if config.Metrics == nil {
+		config.Metrics = GetMetrics()
+	}
+	if config.Thresholds.Window == 0 {
+		config.Thresholds.Window = time.Minute
+	}
+	if config.Thresholds.MinRequests == 0 {
+		config.Thresholds.MinRequests = 20
+	}
+	if config.Thresholds.Cooldown == 0 {
+		config.Thresholds.Cooldown = 10 * time.Minute
+	}
+
+	n := &WebhookNotifier{
+		backend:    backend,
+		config:     config,
+		queue:      make(chan WebhookPayload, config.QueueSize),
+		lastBreach: make(map[string]time.Time),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	for _, url := range config.URLs {
+		n.targets = append(n.targets, &webhookTarget{url: url, queue: make(chan webhookDelivery, config.QueueSize)})
+	}
+	thresholds := config.Thresholds.MaxServerErrors > 0 || config.Thresholds.MaxErrorRate > 0
+	if thresholds {
+		RegisterMetricsSink(n)
+	}
+	go n.run(thresholds)
+	return n
+}
+
+// LogRequest implements Logger interface for WebhookNotifier
+func (n *WebhookNotifier) LogRequest(entry LogEntry) {
+	if n.backend != nil {
+		n.backend.LogRequest(entry)
+	}
+}
+
+// LogResponse implements Logger interface for WebhookNotifier
+func (n *WebhookNotifier) LogResponse(entry LogEntry) {
+	if n.backend != nil {
+		n.backend.LogResponse(entry)
+	}
+}
+
+// LogError implements Logger interface for WebhookNotifier
+func (n *WebhookNotifier) LogError(requestID string, err error) {
+	if n.backend != nil {
+		n.backend.LogError(requestID, err)
+	}
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		entry := panicErr.Entry
+		n.Notify(WebhookPayload{Event: WebhookEventPanic, Entry: &entry, Stack: string(panicErr.Stack)})
+	}
+}
+
+// LogMessage implements MessageLogger interface for WebhookNotifier
+func (n *WebhookNotifier) LogMessage(message string) {
+	if ml, ok := n.backend.(MessageLogger); ok {
+		ml.LogMessage(message)
+		return
+	}
+	log.Println(message)
+}
+
+// LoggerStats implements LoggerStats interface for WebhookNotifier
+func (n *WebhookNotifier) LoggerStats() map[string]interface{} {
+	stats := map[string]interface{}{
+		"webhook": map[string]interface{}{
+			"queue_depth": len(n.queue),
+			"sent":        n.sent.Load(),
+			"failed":      n.failed.Load(),
+			"dropped":     n.dropped.Load(),
+		},
+	}
+	if s, ok := n.backend.(LoggerStats); ok {
+		stats["backend"] = s.LoggerStats()
+	}
+	return stats
+}
+
+// ObserveRequest implements MetricsSink, counting requests for threshold evaluation
+func (n *WebhookNotifier) ObserveRequest(sample RequestSample) {
+	if n.closed.Load() {
+		return
+	}
+	n.requests.Add(1)
+	if sample.StatusCode >= 500 {
+		n.serverErrors.Add(1)
+	}
+}
+
+// Notify queues an event for delivery, filling Time and Metrics when unset.
+// The event is dropped (and counted) when the queue is full or the notifier is closed.
+func (n *WebhookNotifier) Notify(payload WebhookPayload) {
+	if payload.Time.IsZero() {
+		payload.Time = time.Now().UTC()
+	}
+	if payload.Metrics == nil {
+		payload.Metrics = n.metricsExcerpt()
+	}
+	select {
+	case <-n.done:
+		n.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case n.queue <- payload:
+	default:
+		n.dropped.Add(1)
+		logMessage("[WEBHOOK] queue full, dropped %s event", payload.Event)
+	}
+}
+
+// Close stops threshold evaluation, unregisters the metrics sink and waits until
+// queued events are delivered; when ctx is done first, pending deliveries are
+// abandoned and ctx.Err() is returned. The backend is not closed.
+func (n *WebhookNotifier) Close(ctx context.Context) error {
+	n.closeOnce.Do(func() {
+		n.closed.Store(true)
+		UnregisterMetricsSink(n)
+		close(n.done)
+	})
+	select {
+	case <-n.stopped:
+		return nil
+	case <-ctx.Done():
+		n.cancel()
+		return ctx.Err()
+	}
+}
+
+// run fans queued events out to the targets and evaluates thresholds; on Close
+// it flushes the queue and waits for the target workers to finish
+func (n *WebhookNotifier) run(thresholds bool) {
+	defer close(n.stopped)
+	defer n.cancel()
+	var workers sync.WaitGroup
+	for _, t := range n.targets {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for d := range t.queue {
+				n.deliver(t.url, d)
+			}
+		}()
+	}
+	defer func() {
+		for _, t := range n.targets {
+			close(t.queue)
+		}
+		workers.Wait()
+	}()
+
+	var tick <-chan time.Time
+	if thresholds {
+		ticker := time.NewTicker(n.config.Thresholds.Window)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case payload := <-n.queue:
+			n.dispatch(payload, false)
+		case <-tick:
+			n.evaluate()
+		case <-n.done:
+			for {
+				select {
+				case payload := <-n.queue:
+					n.dispatch(payload, true)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// evaluate ends the current window and queues an event for each breached threshold
+func (n *WebhookNotifier) evaluate() {
+	requests, serverErrors := n.requests.Swap(0), n.serverErrors.Swap(0)
+	t := n.config.Thresholds
+	breach := func(name string, value, limit float64) {
+		now := time.Now()
+		if last, ok := n.lastBreach[name]; ok && now.Sub(last) < t.Cooldown {
+			return
+		}
+		n.lastBreach[name] = now
+		n.Notify(WebhookPayload{Event: WebhookEventThresholdBreach, Breach: &WebhookBreach{
+			Threshold: name, Value: value, Limit: limit, Window: t.Window.String(),
+			Requests: requests, ServerErrors: serverErrors,
+		}})
+	}
+
+	if t.MaxServerErrors > 0 && serverErrors >= t.MaxServerErrors {
+		breach("server_errors", float64(serverErrors), float64(t.MaxServerErrors))
+	}
+	if t.MaxErrorRate > 0 && requests >= t.MinRequests {
+		if rate := float64(serverErrors) / float64(requests); rate >= t.MaxErrorRate {
+			breach("error_rate", rate, t.MaxErrorRate)
+		}
+	}
+}
+
+// dispatch encodes an event and queues it on every target. While running, a
+// target whose queue is full drops the event; when flushing on Close it waits
+// for room unless Close has given up.
+func (n *WebhookNotifier) dispatch(payload WebhookPayload, flush bool) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.failed.Add(1)
+		logMessage("[WEBHOOK] encode %s event: %v", payload.Event, err)
+		return
+	}
+	d := webhookDelivery{event: payload.Event, body: body}
+	for _, t := range n.targets {
+		if flush {
+			select {
+			case t.queue <- d:
+			case <-n.ctx.Done():
+				n.dropped.Add(1)
+			}
+			continue
+		}
+		select {
+		case t.queue <- d:
+		default:
+			n.dropped.Add(1)
+			logMessage("[WEBHOOK] queue of %s full, dropped %s event", t.url, payload.Event)
+		}
+	}
+}
+
+// deliver sends an event to one URL and records the outcome
+func (n *WebhookNotifier) deliver(url string, d webhookDelivery) {
+	if err := n.send(url, d.event, d.body); err != nil {
+		n.failed.Add(1)
+		logMessage("[WEBHOOK] %s event to %s failed: %v", d.event, url, err)
+		return
+	}
+	n.sent.Add(1)
+}
+
+// send POSTs body to url, retrying retryable failures after Retry-After or
+// an exponential backoff; waits end early when the notifier is cancelled
+func (n *WebhookNotifier) send(url, event string, body []byte) error {
+	backoff := n.config.Backoff
+	var err error
+	for attempt := 0; attempt <= n.config.MaxRetries; attempt++ {
+		var retryable bool
+		var retryAfter time.Duration
+		if retryAfter, retryable, err = n.attempt(url, event, body); err == nil || !retryable {
+			return err
+		}
+		if attempt == n.config.MaxRetries {
+			break
+		}
+		wait := retryDelay(backoff, retryAfter)
+		backoff *= 2
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-n.ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (abandoned on close)", err)
+		}
+	}
+	return fmt.Errorf("%w (after %d attempts)", err, n.config.MaxRetries+1)
+}
+
+// retryDelay returns the wait before the next attempt: the endpoint's Retry-After
+// capped at maxWebhookRetryAfter when given, the current backoff otherwise
+func retryDelay(backoff, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxWebhookRetryAfter)
+	}
+	return backoff
+}
+
+// attempt makes one delivery, reporting whether a failure is worth retrying and
+// the delay requested by the endpoint's Retry-After header, if any
+func (n *WebhookNotifier) attempt(url, event string, body []byte) (retryAfter time.Duration, retryable bool, err error) {
+	ctx, cancel := context.WithTimeout(n.ctx, n.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event)
+	if len(n.config.Secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(n.config.Secret, timestamp, body))
+	}
+
+	resp, err := n.config.Client.Do(req)
+	if err != nil {
+		return 0, n.ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return 0, false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), true, fmt.Errorf("responded %s", resp.Status)
+	}
+	return 0, false, fmt.Errorf("responded %s", resp.Status)
+}
+
+// parseRetryAfter returns the delay of a Retry-After header given in seconds
+// or as an HTTP date, 0 when absent or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// metricsExcerpt copies the payload's metrics keys from the metrics snapshot
+func (n *WebhookNotifier) metricsExcerpt() map[string]interface{} {
+	snapshot := n.config.Metrics.GetMetrics()
+	excerpt := make(map[string]interface{}, len(webhookMetricsExcerpt))
+	for _, key := range webhookMetricsExcerpt {
+		if v, ok := snapshot[key]; ok {
+			excerpt[key] = v
+		}
+	}
+	return excerpt
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>"
+func signWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
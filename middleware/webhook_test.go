@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver is a test endpoint answering deliveries with the queued statuses
+// (200 once they run out) and recording each request
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+	status := http.StatusOK
+	if len(rcv.statuses) > 0 {
+		status, rcv.statuses = rcv.statuses[0], rcv.statuses[1:]
+	}
+	if status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "0")
+	}
+	w.WriteHeader(status)
+}
+
+// notifyAndClose sends one event through a notifier for config and waits for its delivery
+func notifyAndClose(t *testing.T, config WebhookConfig, notify func(n *WebhookNotifier)) *WebhookNotifier {
+	t.Helper()
+	config.Backoff = time.Millisecond
+	config.Metrics = NewMetrics()
+	n := NewWebhookNotifier(nil, config)
+	notify(n)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return n
+}
+
+func TestWebhookNotifierSignsDeliveries(t *testing.T) {
+	rcv := &webhookReceiver{}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+	secret := []byte("webhook-secret")
+
+	notifyAndClose(t, WebhookConfig{URLs: []string{server.URL}, Secret: secret}, func(n *WebhookNotifier) {
+		n.Notify(WebhookPayload{Event: "goroutine_leak", Details: map[string]interface{}{"count": 3}})
+	})
+
+	if len(rcv.requests) != 1 {
+		t.Fatalf("deliveries: got %d, want 1", len(rcv.requests))
+	}
+	req, body := rcv.requests[0], rcv.bodies[0]
+	if got := req.Header.Get(WebhookEventHeader); got != "goroutine_leak" {
+		t.Fatalf("%s: got %q, want goroutine_leak", WebhookEventHeader, got)
+	}
+	timestamp := req.Header.Get(WebhookTimestampHeader)
+	if unix, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(unix, 0)) > time.Minute {
+		t.Fatalf("%s: got %q, want the current unix time", WebhookTimestampHeader, timestamp)
+	}
+	if got, want := req.Header.Get(WebhookSignatureHeader), "sha256="+signWebhook(secret, timestamp, body); got != want {
+		t.Fatalf("%s: got %q, want %q", WebhookSignatureHeader, got, want)
+	}
+	if signWebhook(secret, timestamp, append(body, ' ')) == signWebhook(secret, timestamp, body) {
+		t.Fatalf("signature does not cover the body")
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("body %q is not a WebhookPayload: %v", body, err)
+	}
+	if payload.Event != "goroutine_leak" || payload.Time.IsZero() || payload.Metrics == nil || payload.Details["count"] != 3.0 {
+		t.Fatalf("payload: got %+v", payload)
+	}
+}
+
+func TestWebhookNotifierOmitsSignatureWithoutSecret(t *testing.T) {
+	rcv := &webhookReceiver{}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+
+	notifyAndClose(t, WebhookConfig{URLs: []string{server.URL}}, func(n *WebhookNotifier) {
+		n.LogError("req-1", &PanicError{Err: errors.New("boom"), Stack: []byte("stack"), Entry: LogEntry{RequestID: "req-1", StatusCode: 500}})
+		n.LogError("req-2", errors.New("not a panic"))
+	})
+
+	if len(rcv.requests) != 1 {
+		t.Fatalf("deliveries: got %d, want 1 (panics only)", len(rcv.requests))
+	}
+	if got := rcv.requests[0].Header.Get(WebhookSignatureHeader); got != "" {
+		t.Fatalf("%s without Secret: got %q, want empty", WebhookSignatureHeader, got)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(rcv.bodies[0], &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != WebhookEventPanic || payload.Entry == nil || payload.Entry.RequestID != "req-1" || payload.Stack != "stack" {
+		t.Fatalf("panic payload: got %+v", payload)
+	}
+}
+
+func TestWebhookNotifierRetries(t *testing.T) {
+	cases := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int
+		wantSent     uint64
+		wantFailed   uint64
+	}{
+		{"5xx and 429 are retried", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, 3, 1, 0},
+		{"4xx is not retried", []int{http.StatusBadRequest}, 1, 0, 1},
+		{"gives up after MaxRetries", []int{500, 500, 500}, 3, 0, 1},
+	}
+	for _, tc := range cases {
+		rcv := &webhookReceiver{statuses: tc.statuses}
+		server := httptest.NewServer(rcv)
+
+		n := notifyAndClose(t, WebhookConfig{URLs: []string{server.URL}, MaxRetries: 2}, func(n *WebhookNotifier) {
+			n.Notify(WebhookPayload{Event: "custom"})
+		})
+		server.Close()
+
+		if len(rcv.requests) != tc.wantAttempts {
+			t.Fatalf("%s: attempts got %d, want %d", tc.name, len(rcv.requests), tc.wantAttempts)
+		}
+		if n.sent.Load() != tc.wantSent || n.failed.Load() != tc.wantFailed {
+			t.Fatalf("%s: sent/failed got %d/%d, want %d/%d", tc.name, n.sent.Load(), n.failed.Load(), tc.wantSent, tc.wantFailed)
+		}
+	}
+}
+
+func TestWebhookRetryDelayCapsRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		retryAfter string
+		want       time.Duration
+	}{
+		{"", 2 * time.Second},
+		{"5", 5 * time.Second},
+		{"86400", maxWebhookRetryAfter},
+		{now.Add(3 * time.Hour).Format(http.TimeFormat), maxWebhookRetryAfter},
+	} {
+		if got := retryDelay(2*time.Second, parseRetryAfter(tc.retryAfter, now)); got != tc.want {
+			t.Fatalf("Retry-After %q: got %v, want %v", tc.retryAfter, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RobotsGroup là một nhóm rule trong robots.txt áp dụng cho các user agent
+type RobotsGroup struct {
+	UserAgents []string      // Mặc định "*"
+	Allow      []string      // Path được phép
+	Disallow   []string      // Path bị cấm
+	CrawlDelay time.Duration // Crawl-delay, 0 là không khai báo
+}
+
+// RobotsConfig cấu hình cho RegisterRobotsTxt
+type RobotsConfig struct {
+	Groups   []RobotsGroup // Mặc định một nhóm cho phép mọi path
+	Sitemaps []string      // URL tuyệt đối của sitemap
+	MaxAge   time.Duration // Cache-Control max-age, mặc định 1 giờ
+}
+
+// Validate kiểm tra tính hợp lệ của RobotsConfig
+func (c RobotsConfig) Validate() error {
+	var errs configErrors
+	for i, group := range c.Groups {
+		errs.checkExactPaths(fmt.Sprintf("Groups[%d].Allow", i), group.Allow)
+		errs.checkExactPaths(fmt.Sprintf("Groups[%d].Disallow", i), group.Disallow)
+		if group.CrawlDelay < 0 {
+			errs.addf("Groups[%d].CrawlDelay must not be negative, got %v", i, group.CrawlDelay)
+		}
+	}
+	for _, sitemap := range c.Sitemaps {
+		if !strings.HasPrefix(sitemap, "http://") && !strings.HasPrefix(sitemap, "https://") {
+			errs.addf("Sitemaps: %q must be an absolute URL", sitemap)
+		}
+	}
+	if c.MaxAge < 0 {
+		errs.addf("MaxAge must not be negative, got %v", c.MaxAge)
+	}
+	return errs.err()
+}
+
+// RegisterRobotsTxt đăng ký route /robots.txt lên router. Route đi qua
+// middleware stack như mọi route khác nên hit được log và đếm trong metrics.
+func RegisterRobotsTxt(r gin.IRoutes, config RobotsConfig) {
+	mustValidate("Robots", config)
+	if len(config.Groups) == 0 {
+		config.Groups = []RobotsGroup{{Allow: []string{"/"}}}
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = time.Hour
+	}
+
+	var b strings.Builder
+	for i, group := range config.Groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		agents := group.UserAgents
+		if len(agents) == 0 {
+			agents = []string{"*"}
+		}
+		for _, agent := range agents {
+			fmt.Fprintf(&b, "User-agent: %s\n", agent)
+		}
+		for _, path := range group.Allow {
+			fmt.Fprintf(&b, "Allow: %s\n", path)
+		}
+		for _, path := range group.Disallow {
+			fmt.Fprintf(&b, "Disallow: %s\n", path)
+		}
+		if group.CrawlDelay > 0 {
+			fmt.Fprintf(&b, "Crawl-delay: %d\n", ceilSeconds(group.CrawlDelay))
+		}
+	}
+	if len(config.Sitemaps) > 0 {
+		b.WriteString("\n")
+	}
+	for _, sitemap := range config.Sitemaps {
+		fmt.Fprintf(&b, "Sitemap: %s\n", sitemap)
+	}
+
+	r.GET("/robots.txt", textFileHandler(b.String(), config.MaxAge))
+}
+
+// SecurityTxtConfig cấu hình cho RegisterSecurityTxt, các field theo RFC 9116
+type SecurityTxtConfig struct {
+	Contact            []string      // Bắt buộc, URI liên hệ (mailto:, https:, tel:)
+	Expires            time.Time     // Mặc định 1 năm kể từ lúc đăng ký
+	Encryption         []string      // URI của public key
+	Acknowledgments    []string      // URI trang ghi nhận
+	PreferredLanguages []string      // Ví dụ "en", "vi"
+	Canonical          []string      // URI chính thức của file
+	Policy             []string      // URI chính sách công bố lỗ hổng
+	Hiring             []string      // URI tuyển dụng bảo mật
+	MaxAge             time.Duration // Cache-Control max-age, mặc định 24 giờ
+}
+
+// Validate kiểm tra tính hợp lệ của SecurityTxtConfig
+func (c SecurityTxtConfig) Validate() error {
+	var errs configErrors
+	if len(c.Contact) == 0 {
+		errs.addf("Contact: at least one contact is required by RFC 9116")
+	}
+	for _, contact := range c.Contact {
+		if !strings.HasPrefix(contact, "mailto:") && !strings.HasPrefix(contact, "https://") && !strings.HasPrefix(contact, "tel:") {
+			errs.addf("Contact: %q must be a mailto:, https:// or tel: URI", contact)
+		}
+	}
+	if !c.Expires.IsZero() && c.Expires.Before(time.Now()) {
+		errs.addf("Expires %s is in the past", c.Expires.Format(time.RFC3339))
+	}
+	if c.MaxAge < 0 {
+		errs.addf("MaxAge must not be negative, got %v", c.MaxAge)
+	}
+	return errs.err()
+}
+
+// RegisterSecurityTxt đăng ký route /.well-known/security.txt (RFC 9116) lên router.
+// Route đi qua middleware stack như mọi route khác nên hit được log và đếm trong metrics.
+func RegisterSecurityTxt(r gin.IRoutes, config SecurityTxtConfig) {
+	mustValidate("SecurityTxt", config)
+	if config.Expires.IsZero() {
+		config.Expires = time.Now().AddDate(1, 0, 0)
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = 24 * time.Hour
+	}
+
+	var b strings.Builder
+	fields := []struct {
+		name   string
+		values []string
+	}{
+		{"Contact", config.Contact},
+		{"Expires", []string{config.Expires.UTC().Format(time.RFC3339)}},
+		{"Encryption", config.Encryption},
+		{"Acknowledgments", config.Acknowledgments},
+		{"Preferred-Languages", []string{strings.Join(config.PreferredLanguages, ", ")}},
+		{"Canonical", config.Canonical},
+		{"Policy", config.Policy},
+		{"Hiring", config.Hiring},
+	}
+	for _, field := range fields {
+		for _, value := range field.values {
+			if value != "" {
+				fmt.Fprintf(&b, "%s: %s\n", field.name, value)
+			}
+		}
+	}
+
+	r.GET("/.well-known/security.txt", textFileHandler(b.String(), config.MaxAge))
+}
+
+// textFileHandler trả về handler phục vụ nội dung text tĩnh kèm Cache-Control
+func textFileHandler(content string, maxAge time.Duration) gin.HandlerFunc {
+	cacheControl := fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", cacheControl)
+		c.Data(200, "text/plain; charset=utf-8", []byte(content))
+	}
+}
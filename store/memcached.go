@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// memcachedMaxRelativeTTL là TTL tương đối lớn nhất; lớn hơn giá trị này
+// memcached hiểu expiration là unix timestamp
+const memcachedMaxRelativeTTL = 30 * 24 * time.Hour
+
+// memcachedMaxKeyLength là độ dài key tối đa của memcached
+const memcachedMaxKeyLength = 250
+
+// memcachedHashedKeyLength là độ dài phần "sha1:"+hex mà key() dùng thay cho key không hợp lệ
+const memcachedHashedKeyLength = len("sha1:") + 2*sha1.Size
+
+// MemcachedClient là tập lệnh Memcached tối thiểu mà MemcachedStore cần.
+// Người dùng viết adapter mỏng cho client đang dùng (ví dụ gomemcache) để
+// package không phụ thuộc cứng vào một thư viện cụ thể.
+type MemcachedClient interface {
+	// Get trả về giá trị của key, found = false nếu cache miss
+	Get(key string) (value []byte, found bool, err error)
+	// Set ghi giá trị, expiration tính bằng giây (hoặc unix timestamp), 0 là không hết hạn
+	Set(key string, value []byte, expiration int32) error
+	// Add chỉ ghi khi key chưa tồn tại, added = false nếu key đã có
+	Add(key string, value []byte, expiration int32) (added bool, err error)
+	// Delete xoá key, không lỗi nếu key không tồn tại
+	Delete(key string) error
+	// Increment cộng delta, found = false nếu key không tồn tại
+	Increment(key string, delta uint64) (value uint64, found bool, err error)
+	// Decrement trừ delta (memcached không cho giá trị âm, tối thiểu là 0)
+	Decrement(key string, delta uint64) (value uint64, found bool, err error)
+}
+
+// MemcachedStore là Store dùng Memcached. Lưu ý giá trị của Incr luôn
+// không âm do giới hạn của memcached.
+type MemcachedStore struct {
+	client MemcachedClient
+	prefix string
+}
+
+// NewMemcachedStore tạo MemcachedStore, mọi key được thêm prefix.
+//
+// Panic nếu prefix chứa khoảng trắng/ký tự điều khiển hoặc dài đến mức key đã
+// băm (prefix + "sha1:" + hex) vượt quá giới hạn 250 byte của memcached.
+func NewMemcachedStore(client MemcachedClient, prefix string) *MemcachedStore {
+	if !validMemcachedKey(prefix) {
+		panic(fmt.Sprintf("store: invalid MemcachedStore prefix %q: must not contain whitespace or control characters", prefix))
+	}
+	if maxLen := memcachedMaxKeyLength - memcachedHashedKeyLength; len(prefix) > maxLen {
+		panic(fmt.Sprintf("store: invalid MemcachedStore prefix: length %d exceeds %d bytes", len(prefix), maxLen))
+	}
+	return &MemcachedStore{client: client, prefix: prefix}
+}
+
+// Get implements Store
+func (s *MemcachedStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	return s.client.Get(s.key(key))
+}
+
+// Set implements Store
+func (s *MemcachedStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(s.key(key), value, memcachedExpiration(ttl))
+}
+
+// Delete implements Store
+func (s *MemcachedStore) Delete(_ context.Context, key string) error {
+	return s.client.Delete(s.key(key))
+}
+
+// Incr implements Store
+func (s *MemcachedStore) Incr(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	k := s.key(key)
+	// Thử tăng trước, nếu key chưa có thì tạo bằng Add; nếu tiến trình khác
+	// vừa tạo key (Add thất bại) thì tăng lại một lần nữa
+	for attempt := 0; attempt < 2; attempt++ {
+		value, found, err := s.step(k, delta)
+		if err != nil {
+			return 0, err
+		}
+		if found {
+			return int64(value), nil
+		}
+
+		initial := max(delta, 0)
+		added, err := s.client.Add(k, []byte(strconv.FormatInt(initial, 10)), memcachedExpiration(ttl))
+		if err != nil {
+			return 0, err
+		}
+		if added {
+			return initial, nil
+		}
+	}
+	return 0, ErrNotInteger
+}
+
+// step gọi Increment hoặc Decrement tuỳ theo dấu của delta
+func (s *MemcachedStore) step(key string, delta int64) (uint64, bool, error) {
+	if delta < 0 {
+		return s.client.Decrement(key, uint64(-delta))
+	}
+	return s.client.Increment(key, uint64(delta))
+}
+
+// key thêm prefix và băm các key không hợp lệ với memcached (quá dài hoặc
+// chứa khoảng trắng/ký tự điều khiển)
+func (s *MemcachedStore) key(key string) string {
+	k := s.prefix + key
+	if len(k) <= memcachedMaxKeyLength && validMemcachedKey(k) {
+		return k
+	}
+	sum := sha1.Sum([]byte(k))
+	return s.prefix + "sha1:" + hex.EncodeToString(sum[:])
+}
+
+// validMemcachedKey kiểm tra key không chứa khoảng trắng hoặc ký tự điều khiển
+func validMemcachedKey(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// memcachedExpiration chuyển ttl sang expiration của memcached
+func memcachedExpiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > memcachedMaxRelativeTTL {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	return int32(max(ttl/time.Second, 1))
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemcachedStoreKeysStayValid(t *testing.T) {
+	prefix := strings.Repeat("p", memcachedMaxKeyLength-memcachedHashedKeyLength)
+	s := NewMemcachedStore(nil, prefix)
+
+	for _, key := range []string{"rate:1.2.3.4", "has space", "ctrl\x01", strings.Repeat("k", 300)} {
+		k := s.key(key)
+		if len(k) > memcachedMaxKeyLength || !validMemcachedKey(k) {
+			t.Fatalf("key(%q) = %q is not a valid memcached key", key, k)
+		}
+		if !strings.HasPrefix(k, prefix) {
+			t.Fatalf("key(%q) = %q lost the prefix", key, k)
+		}
+	}
+}
+
+func TestNewMemcachedStoreRejectsInvalidPrefix(t *testing.T) {
+	for _, prefix := range []string{"my app:", "app\n", strings.Repeat("p", memcachedMaxKeyLength-memcachedHashedKeyLength+1)} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatalf("NewMemcachedStore(%q) did not panic", prefix)
+				}
+			}()
+			NewMemcachedStore(nil, prefix)
+		}()
+	}
+}
@@ -0,0 +1,194 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryConfig cấu hình cho MemoryStore
+type MemoryConfig struct {
+	MaxEntries      int           // Số key tối đa, key ít dùng nhất bị loại khi vượt; <= 0 là không giới hạn
+	CleanupInterval time.Duration // Chu kỳ dọn key hết hạn, mặc định 1 phút
+}
+
+// MemoryStore là Store in-memory với cơ chế LRU + TTL
+type MemoryStore struct {
+	mu      sync.Mutex
+	config  MemoryConfig
+	ll      *list.List
+	entries map[string]*list.Element
+	stats   Stats
+	done    chan struct{}
+	once    sync.Once
+}
+
+// memoryEntry là một phần tử trong danh sách LRU
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryStore tạo MemoryStore và khởi động goroutine dọn key hết hạn.
+// Gọi Close để dừng goroutine khi không dùng nữa.
+func NewMemoryStore(config MemoryConfig) *MemoryStore {
+	if config.CleanupInterval <= 0 {
+		config.CleanupInterval = time.Minute
+	}
+	s := &MemoryStore{
+		config:  config,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+		done:    make(chan struct{}),
+	}
+	go s.cleanupLoop()
+	return s
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.lookup(key, time.Now())
+	if e == nil {
+		s.stats.Misses++
+		return nil, false, nil
+	}
+	s.stats.Hits++
+	value := make([]byte, len(e.value))
+	copy(value, e.value)
+	return value, true, nil
+}
+
+// Set implements Store
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	stored := make([]byte, len(value))
+	copy(stored, value)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(key, stored, expiry(ttl))
+	return nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	return nil
+}
+
+// Incr implements Store
+func (s *MemoryStore) Incr(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.lookup(key, time.Now())
+	if e == nil {
+		s.put(key, []byte(strconv.FormatInt(delta, 10)), expiry(ttl))
+		return delta, nil
+	}
+	current, err := strconv.ParseInt(string(e.value), 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	current += delta
+	e.value = []byte(strconv.FormatInt(current, 10))
+	return current, nil
+}
+
+// Stats implements StatsProvider
+func (s *MemoryStore) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Size = s.ll.Len()
+	return stats
+}
+
+// Close dừng goroutine dọn key hết hạn
+func (s *MemoryStore) Close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// lookup trả về entry còn hạn và đánh dấu vừa được dùng, caller phải giữ lock
+func (s *MemoryStore) lookup(key string, now time.Time) *memoryEntry {
+	el, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*memoryEntry)
+	if !e.expires.IsZero() && now.After(e.expires) {
+		s.remove(el)
+		s.stats.Expirations++
+		return nil
+	}
+	s.ll.MoveToFront(el)
+	return e
+}
+
+// put ghi entry và loại key ít dùng nhất nếu vượt dung lượng, caller phải giữ lock
+func (s *MemoryStore) put(key string, value []byte, expires time.Time) {
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*memoryEntry)
+		e.value, e.expires = value, expires
+		s.ll.MoveToFront(el)
+		return
+	}
+	s.entries[key] = s.ll.PushFront(&memoryEntry{key: key, value: value, expires: expires})
+
+	for s.config.MaxEntries > 0 && s.ll.Len() > s.config.MaxEntries {
+		s.remove(s.ll.Back())
+		s.stats.Evictions++
+	}
+}
+
+// remove xoá một phần tử khỏi store, caller phải giữ lock
+func (s *MemoryStore) remove(el *list.Element) {
+	s.ll.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+}
+
+// cleanupLoop định kỳ xoá các key đã hết hạn
+func (s *MemoryStore) cleanupLoop() {
+	ticker := time.NewTicker(s.config.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.removeExpired(time.Now())
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// removeExpired xoá tất cả key đã hết hạn
+func (s *MemoryStore) removeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for el := s.ll.Back(); el != nil; {
+		prev := el.Prev()
+		e := el.Value.(*memoryEntry)
+		if !e.expires.IsZero() && now.After(e.expires) {
+			s.remove(el)
+			s.stats.Expirations++
+		}
+		el = prev
+	}
+}
+
+// expiry chuyển ttl thành thời điểm hết hạn (zero = không hết hạn)
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
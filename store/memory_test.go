@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(MemoryConfig{MaxEntries: 2})
+	defer s.Close()
+
+	_ = s.Set(ctx, "a", []byte("1"), 0)
+	_ = s.Set(ctx, "b", []byte("2"), 0)
+	// Đọc "a" để "b" trở thành key ít dùng nhất
+	if _, ok, _ := s.Get(ctx, "a"); !ok {
+		t.Fatal("a: want found")
+	}
+	_ = s.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := s.Get(ctx, "b"); ok {
+		t.Fatal("b: want evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok, _ := s.Get(ctx, key); !ok {
+			t.Fatalf("%s: want found", key)
+		}
+	}
+	if stats := s.Stats(); stats.Evictions != 1 || stats.Size != 2 {
+		t.Fatalf("stats: got %+v, want 1 eviction and size 2", stats)
+	}
+}
+
+func TestMemoryStoreExpiresKeys(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(MemoryConfig{})
+	defer s.Close()
+
+	const ttl = 20 * time.Millisecond
+	_ = s.Set(ctx, "short", []byte("x"), ttl)
+	_ = s.Set(ctx, "forever", []byte("y"), 0)
+	time.Sleep(2 * ttl)
+
+	if _, ok, _ := s.Get(ctx, "short"); ok {
+		t.Fatal("short: want expired")
+	}
+	if v, ok, _ := s.Get(ctx, "forever"); !ok || string(v) != "y" {
+		t.Fatalf("forever: got %q, %v, want \"y\", true", v, ok)
+	}
+	if stats := s.Stats(); stats.Expirations != 1 {
+		t.Fatalf("expirations: got %d, want 1", stats.Expirations)
+	}
+}
+
+func TestMemoryStoreRemoveExpired(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(MemoryConfig{})
+	defer s.Close()
+
+	_ = s.Set(ctx, "a", []byte("1"), time.Minute)
+	_ = s.Set(ctx, "b", []byte("2"), 0)
+	s.removeExpired(time.Now().Add(2 * time.Minute))
+
+	if stats := s.Stats(); stats.Size != 1 || stats.Expirations != 1 {
+		t.Fatalf("stats: got %+v, want size 1 and 1 expiration", stats)
+	}
+}
+
+func TestMemoryStoreIncrKeepsTTLFromCreation(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(MemoryConfig{})
+	defer s.Close()
+
+	const ttl = 40 * time.Millisecond
+	if n, _ := s.Incr(ctx, "counter", 1, ttl); n != 1 {
+		t.Fatalf("first incr: got %d, want 1", n)
+	}
+	time.Sleep(ttl / 2)
+	// Incr tiếp theo không gia hạn TTL (fixed window dựa vào điều này)
+	if n, _ := s.Incr(ctx, "counter", 2, ttl); n != 3 {
+		t.Fatalf("second incr: got %d, want 3", n)
+	}
+	time.Sleep(ttl)
+	if n, _ := s.Incr(ctx, "counter", 1, ttl); n != 1 {
+		t.Fatalf("incr after expiry: got %d, want 1", n)
+	}
+}
+
+func TestMemoryStoreIncrRejectsNonInteger(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(MemoryConfig{})
+	defer s.Close()
+
+	_ = s.Set(ctx, "text", []byte("abc"), 0)
+	if _, err := s.Incr(ctx, "text", 1, 0); err != ErrNotInteger {
+		t.Fatalf("got %v, want ErrNotInteger", err)
+	}
+}
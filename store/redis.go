@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RedisClient là tập lệnh Redis tối thiểu dùng chung cho mọi tính năng dựa
+// trên Redis. Người dùng viết adapter mỏng cho client đang dùng (go-redis,
+// rueidis, cluster client, ...) để package không phụ thuộc cứng vào một thư
+// viện Redis cụ thể.
+type RedisClient interface {
+	// Get trả về giá trị của key, found = false nếu key không tồn tại
+	Get(ctx context.Context, key string) (value string, found bool, err error)
+	// Set ghi giá trị với ttl (ttl <= 0 nghĩa là không hết hạn)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// Incr cộng delta vào key (INCRBY) và trả về giá trị mới
+	Incr(ctx context.Context, key string, delta int64) (int64, error)
+	// Expire đặt thời gian sống cho key
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	// Eval chạy Lua script (EVAL) với keys và args, trả về kết quả thô của Redis
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// redisIncrScript tăng giá trị và đặt TTL trong cùng một thao tác atomic khi
+// key chưa có TTL (vừa được tạo). Kiểm tra PTTL thay vì so giá trị với delta
+// để key đang bằng 0 được tăng lên không bị gia hạn.
+const redisIncrScript = `
+local v = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return v`
+
+// redisDelScript xoá key
+const redisDelScript = `return redis.call('DEL', KEYS[1])`
+
+// RedisStore là Store dùng Redis, phù hợp cho các tính năng phân tán
+type RedisStore struct {
+	client RedisClient
+	prefix string
+}
+
+// NewRedisStore tạo RedisStore, mọi key được thêm prefix
+func NewRedisStore(client RedisClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Get implements Store
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, found, err := s.client.Get(ctx, s.prefix+key)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	return []byte(value), true, nil
+}
+
+// Set implements Store
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+key, string(value), ttl)
+}
+
+// Delete implements Store
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	_, err := s.client.Eval(ctx, redisDelScript, []string{s.prefix + key})
+	return err
+}
+
+// Incr implements Store. INCRBY và PEXPIRE chạy trong một script nên key
+// không bao giờ bị kẹt lại mà không có TTL.
+func (s *RedisStore) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	result, err := s.client.Eval(ctx, redisIncrScript, []string{s.prefix + key}, delta, ttl.Milliseconds())
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(result)
+}
+
+// toInt64 chuyển kết quả số nguyên của Redis về int64
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil
+	default:
+		return 0, fmt.Errorf("store: unexpected redis integer reply %T", v)
+	}
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeRedis là RedisClient in-memory với đồng hồ điều khiển được. Eval chạy các
+// script của package bằng các lệnh Redis tương ứng (INCRBY, PTTL, PEXPIRE, DEL) theo
+// đúng thứ tự và điều kiện trong script.
+type fakeRedis struct {
+	RedisClient
+	now      time.Time
+	values   map[string]int64
+	expires  map[string]time.Time
+	pexpires int // Số lần PEXPIRE được gọi
+	evals    []fakeEval
+}
+
+// fakeEval là một lần gọi Eval được fakeRedis ghi lại
+type fakeEval struct {
+	script string
+	keys   []string
+	args   []interface{}
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		now:     time.Unix(1_700_000_000, 0),
+		values:  make(map[string]int64),
+		expires: make(map[string]time.Time),
+	}
+}
+
+// advance cho đồng hồ chạy thêm d
+func (f *fakeRedis) advance(d time.Duration) {
+	f.now = f.now.Add(d)
+}
+
+// expire xoá key đã hết hạn như Redis làm khi key được truy cập
+func (f *fakeRedis) expire(key string) {
+	if at, ok := f.expires[key]; ok && !f.now.Before(at) {
+		delete(f.values, key)
+		delete(f.expires, key)
+	}
+}
+
+func (f *fakeRedis) incrBy(key string, delta int64) int64 {
+	f.expire(key)
+	f.values[key] += delta
+	return f.values[key]
+}
+
+// pttl trả về TTL còn lại theo millisecond, -2 nếu không có key, -1 nếu không có TTL
+func (f *fakeRedis) pttl(key string) int64 {
+	f.expire(key)
+	if _, ok := f.values[key]; !ok {
+		return -2
+	}
+	at, ok := f.expires[key]
+	if !ok {
+		return -1
+	}
+	return at.Sub(f.now).Milliseconds()
+}
+
+func (f *fakeRedis) pexpire(key string, ms int64) {
+	f.pexpires++
+	f.expires[key] = f.now.Add(time.Duration(ms) * time.Millisecond)
+}
+
+func (f *fakeRedis) Eval(_ context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	f.evals = append(f.evals, fakeEval{script: script, keys: keys, args: args})
+	key := keys[0]
+	switch script {
+	case redisIncrScript:
+		delta, ttl := args[0].(int64), args[1].(int64)
+		v := f.incrBy(key, delta)
+		if ttl > 0 && f.pttl(key) == -1 {
+			f.pexpire(key, ttl)
+		}
+		return v, nil
+	case redisDelScript:
+		f.expire(key)
+		_, ok := f.values[key]
+		delete(f.values, key)
+		delete(f.expires, key)
+		if ok {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+	return nil, fmt.Errorf("fakeRedis: unknown script %q", script)
+}
+
+func TestRedisStoreIncrUsesSingleScript(t *testing.T) {
+	client := newFakeRedis()
+	s := NewRedisStore(client, "app:")
+
+	n, err := s.Incr(context.Background(), "hits", 2, 1500*time.Millisecond)
+	if err != nil || n != 2 {
+		t.Fatalf("got %d, %v, want 2, nil", n, err)
+	}
+	if len(client.evals) != 1 {
+		t.Fatalf("got %d Eval calls, want 1", len(client.evals))
+	}
+	call := client.evals[0]
+	if call.script != redisIncrScript {
+		t.Fatalf("script: got %q, want redisIncrScript", call.script)
+	}
+	if len(call.keys) != 1 || call.keys[0] != "app:hits" {
+		t.Fatalf("keys: got %v, want [app:hits]", call.keys)
+	}
+	if len(call.args) != 2 || call.args[0] != int64(2) || call.args[1] != int64(1500) {
+		t.Fatalf("args: got %v, want [2 1500] (delta, ttl in ms)", call.args)
+	}
+}
+
+func TestRedisStoreIncrSetsTTLOnlyOnCreation(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeRedis()
+	s := NewRedisStore(client, "")
+
+	const ttl = 100 * time.Millisecond
+	if n, _ := s.Incr(ctx, "window", 1, ttl); n != 1 {
+		t.Fatalf("first incr: got %d, want 1", n)
+	}
+	client.advance(60 * time.Millisecond)
+	// Incr tiếp theo không gia hạn TTL (fixed window dựa vào điều này)
+	if n, _ := s.Incr(ctx, "window", 1, ttl); n != 2 {
+		t.Fatalf("second incr: got %d, want 2", n)
+	}
+	if client.pexpires != 1 {
+		t.Fatalf("PEXPIRE calls: got %d, want 1", client.pexpires)
+	}
+	client.advance(60 * time.Millisecond)
+	if n, _ := s.Incr(ctx, "window", 1, ttl); n != 1 {
+		t.Fatalf("incr after expiry: got %d, want 1 (new window)", n)
+	}
+}
+
+func TestRedisStoreIncrDoesNotExtendTTLOfKeyAtZero(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeRedis()
+	s := NewRedisStore(client, "")
+
+	// Bộ đếm concurrency về 0 rồi tăng lại: giá trị mới bằng delta nhưng key
+	// không mới, TTL phải giữ nguyên
+	const ttl = 100 * time.Millisecond
+	_, _ = s.Incr(ctx, "slots", 1, ttl)
+	_, _ = s.Incr(ctx, "slots", -1, ttl)
+	client.advance(60 * time.Millisecond)
+	if n, _ := s.Incr(ctx, "slots", 1, ttl); n != 1 {
+		t.Fatalf("incr from zero: got %d, want 1", n)
+	}
+	if client.pexpires != 1 {
+		t.Fatalf("PEXPIRE calls: got %d, want 1", client.pexpires)
+	}
+	client.advance(60 * time.Millisecond)
+	if client.pttl("slots") != -2 {
+		t.Fatal("slots: want expired at the original TTL")
+	}
+}
+
+func TestRedisStoreIncrWithoutTTL(t *testing.T) {
+	client := newFakeRedis()
+	s := NewRedisStore(client, "")
+
+	_, _ = s.Incr(context.Background(), "total", 1, 0)
+	if client.pexpires != 0 {
+		t.Fatalf("PEXPIRE calls: got %d, want 0 for ttl <= 0", client.pexpires)
+	}
+}
+
+// replyRedis là RedisClient mà Eval luôn trả về reply cố định
+type replyRedis struct {
+	RedisClient
+	reply interface{}
+}
+
+func (r replyRedis) Eval(context.Context, string, []string, ...interface{}) (interface{}, error) {
+	return r.reply, nil
+}
+
+func TestRedisStoreIncrRejectsUnexpectedReply(t *testing.T) {
+	s := NewRedisStore(replyRedis{reply: "7"}, "")
+	if _, err := s.Incr(context.Background(), "hits", 1, time.Second); err == nil {
+		t.Fatal("want error for non-integer reply")
+	}
+}
@@ -0,0 +1,40 @@
+// Package store cung cấp interface lưu trữ key-value có TTL dùng chung cho
+// các tính năng của middleware (rate limit, idempotency, cache, ...),
+// cùng các implementation in-memory (LRU + TTL) và Redis.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotInteger được trả về khi Incr trên một key không chứa số nguyên
+var ErrNotInteger = errors.New("store: value is not an integer")
+
+// Store là interface lưu trữ key-value có TTL
+type Store interface {
+	// Get trả về giá trị của key, found = false nếu key không tồn tại hoặc đã hết hạn
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	// Set ghi giá trị với thời gian sống ttl (ttl <= 0 nghĩa là không hết hạn)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete xoá key, không lỗi nếu key không tồn tại
+	Delete(ctx context.Context, key string) error
+	// Incr cộng delta vào giá trị số nguyên của key và trả về giá trị mới.
+	// Nếu key chưa tồn tại, key được tạo với giá trị delta và thời gian sống ttl.
+	Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+}
+
+// Stats là thống kê của một Store
+type Stats struct {
+	Size        int    `json:"size"`        // Số key hiện tại (-1 nếu không xác định)
+	Hits        uint64 `json:"hits"`        // Số lần Get tìm thấy key
+	Misses      uint64 `json:"misses"`      // Số lần Get không tìm thấy key
+	Evictions   uint64 `json:"evictions"`   // Số key bị loại do vượt dung lượng
+	Expirations uint64 `json:"expirations"` // Số key bị xoá do hết hạn
+}
+
+// StatsProvider được implement bởi các Store có thống kê
+type StatsProvider interface {
+	Stats() Stats
+}